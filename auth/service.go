@@ -2,28 +2,29 @@ package auth
 
 import (
 	"errors"
-	"os"
 
+	"github.com/TimDebug/FitByte/config"
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/joho/godotenv"
+	"github.com/samber/do/v2"
 )
 
 type Service interface {
 	GenerateToken(userID string) (string, error)
-	ValidateToken(encodedToken string) (*jwt.Token, error)
+	ParseToken(encodedToken string) (id string, err error)
 }
 
 type jwtService struct {
+	secretKey []byte
 }
 
-func NewJWTService() *jwtService {
-	return &jwtService{}
+func NewJWTService(secretKey string) *jwtService {
+	return &jwtService{secretKey: []byte(secretKey)}
 }
 
-const ENV_PATH = ".env"
-
-var _ = godotenv.Load(ENV_PATH)
-var SECRET_KEY = os.Getenv("JWT_SECRET_KEY")
+func NewJWTServiceInject(i do.Injector) (Service, error) {
+	cfg := do.MustInvoke[*config.Config](i)
+	return NewJWTService(cfg.Auth.JWTSecret.Reveal()), nil
+}
 
 func (s *jwtService) GenerateToken(userID string) (string, error) {
 	claim := jwt.MapClaims{}
@@ -31,7 +32,7 @@ func (s *jwtService) GenerateToken(userID string) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
 
-	signedToken, err := token.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+	signedToken, err := token.SignedString(s.secretKey)
 	if err != nil {
 		return signedToken, err
 	}
@@ -39,13 +40,13 @@ func (s *jwtService) GenerateToken(userID string) (string, error) {
 	return signedToken, nil
 }
 
-func ParseToken(tokenString string) (id string, err error) {
+func (s *jwtService) ParseToken(tokenString string) (id string, err error) {
 	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
 		if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
 			return nil, errors.New("invalid token signing method")
 		}
 
-		return []byte(os.Getenv("JWT_SECRET_KEY")), nil
+		return s.secretKey, nil
 	})
 
 	if err != nil {
@@ -61,7 +62,10 @@ func ParseToken(tokenString string) (id string, err error) {
 		return "", errors.New("invalid token")
 	}
 
-	userID := claims["user_id"].(string)
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return "", errors.New("invalid token")
+	}
 
 	return userID, nil
 }
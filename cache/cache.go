@@ -1,126 +1,91 @@
 package cache
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"fmt"
 	"sync/atomic"
 	"time"
 
-	"github.com/dgraph-io/ristretto/v2"
+	"github.com/TimDebug/FitByte/config"
+	"github.com/samber/do/v2"
 )
 
 const (
 	MaxCacheSize = 256 << 20 //  256 MB
 	DefaultTtl   = 5 * time.Minute
 
-	CacheAuthEmailToToken      = "auth:%s"
-	CacheUserIdToProfile       = "user:%s"
-	CacheInvalidatedUserIds    = "inv_usr" // Value is comma-separated, e.g., 1,3,5
-	CacheEmployeesWithParams   = "employees:v%d:%s"
-	CacheDepartmentsWithParams = "departments:v%d:%s"
+	CacheAuthEmailToToken     = "auth:%s"
+	CacheUserNamespace        = "user_ns:%s"
+	CacheUserIdToProfile      = "user:%s:v%d"
+	CacheActivitiesWithParams = "activities:%s:v%d:%s"
+	CacheHttpResponse         = "http:%s"
+	CacheSessionWrote         = "ryw:%s" // Value is the time of the write in Unix nanoseconds
 )
 
-var (
-	EmployeeNamespaceVersion   atomic.Int64
-	DepartmentNamespaceVersion atomic.Int64
-)
-
-var Cache *ristretto.Cache[string, string]
+var ttl atomic.Int64
 
-func Initialize() {
-	var err error
-
-	Cache, err = ristretto.NewCache(&ristretto.Config[string, string]{
-		NumCounters: 1e6, // 1 million counters for frequency tracking
-		MaxCost:     MaxCacheSize,
-		BufferItems: 64, // 64 keys per Get buffer
-	})
-	if err != nil {
-		log.Fatalf("failed to initialize cache: %v", err)
-	}
-
-	EmployeeNamespaceVersion.Store(1)
-	DepartmentNamespaceVersion.Store(1)
+func init() {
+	SetTtl(DefaultTtl)
 }
 
-func Set(key string, value string) {
-	cost := int64(len(key) + len(value))
-	SetWithCost(key, value, cost)
+// Ttl is how long the services cache entries, DefaultTtl unless changed by
+// the runtime configuration.
+func Ttl() time.Duration {
+	return time.Duration(ttl.Load())
 }
 
-func SetAsMap(key string, value map[string]string) {
-	data, err := json.Marshal(value)
-	if err != nil {
-		panic(err)
-	}
-
-	cost := int64(len(key) + len(data))
-
-	SetWithCost(key, string(data), cost)
+func SetTtl(d time.Duration) {
+	ttl.Store(int64(d))
 }
 
-func SetAsMapArrayWithTtlAndCostMultiplier(
-	key string,
-	value []map[string]string,
-	costMultiplier int,
-	ttl time.Duration,
-) {
-	data, err := json.Marshal(value)
-	if err != nil {
-		panic(err)
-	}
-
-	totalCost := int64(len(key))
-	for _, v := range value {
-		for k, str := range v {
-			stringCost := int64(len(k)) + int64(len(str))
-			totalCost += stringCost
-		}
-	}
-
-	Cache.SetWithTTL(key, string(data), totalCost*int64(costMultiplier), ttl)
+// Store is a key/value cache shared by the services. A ttl <= 0 keeps the
+// entry until it is evicted or deleted.
+type Store interface {
+	// Get returns the cached value and whether it was found.
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Set stores value under key for the given ttl.
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	// Delete removes the given keys, missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+	// Incr atomically increments the counter stored at key and returns the
+	// new value, the counter expires ttl after the last increment. A missing
+	// counter starts from counterBase, so one lost to expiry, eviction or a
+	// restart never repeats an earlier value.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Close releases the resources held by the store.
+	Close() error
 }
 
-func SetWithCost(key string, value string, cost int64) {
-	Cache.SetWithTTL(key, value, cost, DefaultTtl)
+// counterBase is the current time in nanoseconds, above every value of a
+// counter started earlier unless it was incremented once per nanosecond.
+func counterBase() int64 {
+	return time.Now().UnixNano()
 }
 
-func Get(key string) (string, bool) {
-	return Cache.Get(key)
+// Shared reports whether store is seen by every instance of the API and
+// outlives a restart of one. Only then does a namespace version identify
+// the data of a user, an in-process store misses writes made elsewhere.
+func Shared(store Store) bool {
+	_, ok := Unwrap(store).(*RedisStore)
+	return ok
 }
 
-func GetAsMap(key string) (map[string]string, bool) {
-	val, found := Cache.Get(key)
-
-	if !found {
-		return nil, false
-	}
-
-	var result map[string]string
-	err := json.Unmarshal([]byte(val), &result)
-	if err != nil {
-		log.Printf("failed to unmarshal cache value: %v", val)
-		panic(err)
+func NewStore(cfg config.CacheConfig) (Store, error) {
+	switch cfg.Backend {
+	case config.CacheBackendMemory:
+		return NewMemoryStore(MaxCacheSize)
+	case config.CacheBackendRedis:
+		return NewRedisStore(cfg.RedisAddr, cfg.RedisPassword.Reveal(), cfg.RedisDB)
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
 	}
-
-	return result, true
 }
 
-func GetAsMapArray(key string) ([]map[string]string, bool) {
-	val, found := Cache.Get(key)
-	if !found {
-		return nil, false
-	}
-
-	var result []map[string]string
-	err := json.Unmarshal([]byte(val), &result)
+func NewStoreInject(i do.Injector) (Store, error) {
+	cfg := do.MustInvoke[*config.Config](i)
+	store, err := NewStore(cfg.Cache)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-
-	return result, true
-}
-
-func Delete(key string) {
-	Cache.Del(key)
+	return NewTracedStore(store), nil
 }
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+// MemoryStore keeps entries in a per-instance ristretto cache. Entries are not
+// shared between replicas, use RedisStore when running more than one instance.
+type MemoryStore struct {
+	cache *ristretto.Cache[string, string]
+
+	// Counters live outside ristretto, its admission policy may drop writes.
+	// They expire like entries and at most maxCounters are kept.
+	countersMu sync.RWMutex
+	counters   map[string]counter
+}
+
+// maxCounters bounds the counters of a MemoryStore, about one per active
+// user. When full the expired ones and then the longest idle are dropped.
+const maxCounters = 100_000
+
+type counter struct {
+	value   int64
+	expires time.Time // zero never expires
+}
+
+func (c counter) expired(now time.Time) bool {
+	return !c.expires.IsZero() && !now.Before(c.expires)
+}
+
+func NewMemoryStore(maxCost int64) (*MemoryStore, error) {
+	cache, err := ristretto.NewCache(&ristretto.Config[string, string]{
+		NumCounters: 1e6, // 1 million counters for frequency tracking
+		MaxCost:     maxCost,
+		BufferItems: 64, // 64 keys per Get buffer
+		Metrics:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemoryStore{cache: cache, counters: make(map[string]counter)}, nil
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string) (string, bool, error) {
+	m.countersMu.RLock()
+	c, isCounter := m.counters[key]
+	m.countersMu.RUnlock()
+	if isCounter && !c.expired(time.Now()) {
+		return strconv.FormatInt(c.value, 10), true, nil
+	}
+
+	value, found := m.cache.Get(key)
+	return value, found, nil
+}
+
+func (m *MemoryStore) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	cost := int64(len(key) + len(value))
+	m.SetWithCost(key, value, cost, ttl)
+	return nil
+}
+
+func (m *MemoryStore) SetWithCost(key string, value string, cost int64, ttl time.Duration) {
+	m.cache.SetWithTTL(key, value, cost, max(ttl, 0))
+}
+
+// Wait blocks until the pending Sets are applied, ristretto buffers them.
+func (m *MemoryStore) Wait() {
+	m.cache.Wait()
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	m.countersMu.Lock()
+	defer m.countersMu.Unlock()
+	for _, key := range keys {
+		delete(m.counters, key)
+		m.cache.Del(key)
+	}
+	return nil
+}
+
+func (m *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.countersMu.Lock()
+	defer m.countersMu.Unlock()
+
+	now := time.Now()
+	c, found := m.counters[key]
+	if !found || c.expired(now) {
+		if len(m.counters) >= maxCounters {
+			m.evictCounters(now)
+		}
+		c.value = counterBase()
+	}
+	c.value++
+	c.expires = time.Time{}
+	if ttl > 0 {
+		c.expires = now.Add(ttl)
+	}
+	m.counters[key] = c
+	return c.value, nil
+}
+
+// evictCounters drops the expired counters, and then the ones expiring
+// first until a tenth of maxCounters is free.
+func (m *MemoryStore) evictCounters(now time.Time) {
+	for key, c := range m.counters {
+		if c.expired(now) {
+			delete(m.counters, key)
+		}
+	}
+	excess := len(m.counters) - maxCounters*9/10
+	if excess <= 0 {
+		return
+	}
+
+	type entry struct {
+		key     string
+		expires time.Time
+	}
+	entries := make([]entry, 0, len(m.counters))
+	for key, c := range m.counters {
+		entries = append(entries, entry{key, c.expires})
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		// counters without a ttl are the last to go
+		switch {
+		case a.expires.IsZero() && b.expires.IsZero():
+			return 0
+		case a.expires.IsZero():
+			return 1
+		case b.expires.IsZero():
+			return -1
+		}
+		return a.expires.Compare(b.expires)
+	})
+	for _, e := range entries[:excess] {
+		delete(m.counters, e.key)
+	}
+}
+
+// Metrics returns the hit, miss and eviction counters of the cache.
+func (m *MemoryStore) Metrics() *ristretto.Metrics {
+	return m.cache.Metrics
+}
+
+func (m *MemoryStore) Close() error {
+	m.cache.Close()
+	return nil
+}
+
+// Shutdown closes the store when the injector shuts down.
+func (m *MemoryStore) Shutdown() error {
+	return m.Close()
+}
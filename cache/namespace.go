@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// namespaceTtl is how long an unused version is kept. A new one is always
+// above the old one, so an expired version cannot revive stale entries.
+const namespaceTtl = 24 * time.Hour
+
+// UserNamespace versions every cache entry belonging to a user. Entry keys
+// embed the current version, so bumping it invalidates all of them at once
+// and the stale entries simply expire.
+type UserNamespace struct {
+	store Store
+}
+
+func NewUserNamespace(store Store) *UserNamespace {
+	return &UserNamespace{store: store}
+}
+
+// Version is the user's current version. A user without one, e.g. after a
+// restart of a memory store, starts a new version above every earlier one.
+func (n *UserNamespace) Version(ctx context.Context, userId string) (int64, error) {
+	key := fmt.Sprintf(CacheUserNamespace, userId)
+	raw, found, err := n.store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return n.store.Incr(ctx, key, namespaceTtl)
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+// Bump must be called after every write to the user's profile or activities.
+func (n *UserNamespace) Bump(ctx context.Context, userId string) error {
+	_, err := n.store.Incr(ctx, fmt.Sprintf(CacheUserNamespace, userId), namespaceTtl)
+	return err
+}
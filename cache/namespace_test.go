@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestUserNamespaceBump(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			namespace := NewUserNamespace(store)
+
+			first, err := namespace.Version(ctx, "user-1")
+			if err != nil {
+				t.Fatalf("Version: %v", err)
+			}
+			if again, _ := namespace.Version(ctx, "user-1"); again != first {
+				t.Fatalf("Version = %d, then %d without a bump", first, again)
+			}
+
+			for want := first + 1; want <= first+3; want++ {
+				if err := namespace.Bump(ctx, "user-1"); err != nil {
+					t.Fatalf("Bump: %v", err)
+				}
+				if version, _ := namespace.Version(ctx, "user-1"); version != want {
+					t.Fatalf("Version = %d, want %d", version, want)
+				}
+			}
+
+			other, _ := namespace.Version(ctx, "user-2")
+			if version, _ := namespace.Version(ctx, "user-1"); version != first+3 || other == first+3 {
+				t.Fatalf("versions of user-1 and user-2 are %d and %d", version, other)
+			}
+		})
+	}
+}
+
+func TestUserNamespaceNeverRepeatsAVersion(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			namespace := NewUserNamespace(store)
+			namespace.Bump(ctx, "user-1")
+			before, _ := namespace.Version(ctx, "user-1")
+
+			// As after an eviction, expiry or restart of the store
+			store.Delete(ctx, fmt.Sprintf(CacheUserNamespace, "user-1"))
+
+			after, err := namespace.Version(ctx, "user-1")
+			if err != nil || after <= before {
+				t.Fatalf("Version = %d, %v after losing the counter at %d; want a higher one", after, err, before)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreBoundsCounters(t *testing.T) {
+	store, err := NewMemoryStore(MaxCacheSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	for i := 0; i <= maxCounters; i++ {
+		store.Incr(ctx, fmt.Sprintf("counter-%d", i), namespaceTtl)
+	}
+	if n := len(store.counters); n > maxCounters {
+		t.Fatalf("%d counters kept, want at most %d", n, maxCounters)
+	}
+	// The latest one survives the eviction
+	if _, found, _ := store.Get(ctx, fmt.Sprintf("counter-%d", maxCounters)); !found {
+		t.Fatal("the newest counter was evicted")
+	}
+}
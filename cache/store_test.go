@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestStores(t *testing.T) map[string]Store {
+	t.Helper()
+
+	memory, err := NewMemoryStore(MaxCacheSize)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+
+	// Use a real redis-server when TEST_REDIS_ADDR is set, miniredis otherwise
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		server := miniredis.RunT(t)
+		addr = server.Addr()
+	}
+	redisStore, err := NewRedisStore(addr, "", 0)
+	if err != nil {
+		t.Fatalf("NewRedisStore: %v", err)
+	}
+
+	stores := map[string]Store{"memory": memory, "redis": redisStore}
+	t.Cleanup(func() {
+		for _, store := range stores {
+			store.Close()
+		}
+	})
+	return stores
+}
+
+// waitForMemory flushes ristretto's write buffer so a Set is visible to Get.
+func waitForMemory(store Store) {
+	if memoryStore, ok := store.(*MemoryStore); ok {
+		memoryStore.Wait()
+	}
+}
+
+func TestStoreSetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			key := "store-test:" + name
+
+			if _, found, err := store.Get(ctx, key); err != nil || found {
+				t.Fatalf("Get before Set = found %v, err %v", found, err)
+			}
+
+			if err := store.Set(ctx, key, "value", time.Minute); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			waitForMemory(store)
+
+			value, found, err := store.Get(ctx, key)
+			if err != nil || !found || value != "value" {
+				t.Fatalf("Get = %q, %v, %v; want \"value\", true, nil", value, found, err)
+			}
+
+			if err := store.Delete(ctx, key, "missing-key"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, found, _ := store.Get(ctx, key); found {
+				t.Fatalf("key still present after Delete")
+			}
+		})
+	}
+}
+
+func TestRedisStoreSharesInvalidations(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+
+	first, _ := NewRedisStore(server.Addr(), "", 0)
+	second, _ := NewRedisStore(server.Addr(), "", 0)
+	defer first.Close()
+	defer second.Close()
+
+	if err := first.Set(ctx, "shared", "v1", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if value, found, _ := second.Get(ctx, "shared"); !found || value != "v1" {
+		t.Fatalf("second instance Get = %q, %v; want \"v1\", true", value, found)
+	}
+
+	if err := second.Delete(ctx, "shared"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, found, _ := first.Get(ctx, "shared"); found {
+		t.Fatalf("first instance still sees a key deleted by the second")
+	}
+}
+
+func TestRedisStoreTtl(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	store, _ := NewRedisStore(server.Addr(), "", 0)
+	defer store.Close()
+
+	if err := store.Set(ctx, "ttl", "v", time.Second); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	server.FastForward(2 * time.Second)
+
+	if _, found, _ := store.Get(ctx, "ttl"); found {
+		t.Fatalf("key should have expired")
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/TimDebug/FitByte/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedStore wraps a Store with a span per call. Keys may hold emails or
+// user ids, a span only carries the part before the first colon.
+type TracedStore struct {
+	next Store
+}
+
+func NewTracedStore(next Store) TracedStore {
+	return TracedStore{next: next}
+}
+
+func (t TracedStore) start(ctx context.Context, operation string, key string) (context.Context, trace.Span) {
+	prefix, _, _ := strings.Cut(key, ":")
+	return tracing.Start(ctx, "cache "+operation, attribute.String("cache.key_prefix", prefix))
+}
+
+func (t TracedStore) Get(ctx context.Context, key string) (value string, found bool, err error) {
+	ctx, span := t.start(ctx, "Get", key)
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", found))
+		tracing.End(span, err)
+	}()
+	return t.next.Get(ctx, key)
+}
+
+func (t TracedStore) Set(ctx context.Context, key string, value string, ttl time.Duration) (err error) {
+	ctx, span := t.start(ctx, "Set", key)
+	defer func() { tracing.End(span, err) }()
+	return t.next.Set(ctx, key, value, ttl)
+}
+
+func (t TracedStore) Delete(ctx context.Context, keys ...string) (err error) {
+	ctx, span := tracing.Start(ctx, "cache Delete", attribute.Int("cache.keys", len(keys)))
+	defer func() { tracing.End(span, err) }()
+	return t.next.Delete(ctx, keys...)
+}
+
+func (t TracedStore) Incr(ctx context.Context, key string, ttl time.Duration) (value int64, err error) {
+	ctx, span := t.start(ctx, "Incr", key)
+	defer func() { tracing.End(span, err) }()
+	return t.next.Incr(ctx, key, ttl)
+}
+
+func (t TracedStore) Close() error {
+	return t.next.Close()
+}
+
+// HealthCheck checks the wrapped store, if it can be checked.
+func (t TracedStore) HealthCheck(ctx context.Context) error {
+	if checker, ok := t.next.(interface{ HealthCheck(context.Context) error }); ok {
+		return checker.HealthCheck(ctx)
+	}
+	return nil
+}
+
+// Shutdown closes the store when the injector shuts down.
+func (t TracedStore) Shutdown() error {
+	return t.Close()
+}
+
+// Unwrap returns the store t traces.
+func (t TracedStore) Unwrap() Store {
+	return t.next
+}
+
+// Unwrap returns the store behind any decorators of store, e.g. to tell the
+// backend.
+func Unwrap(store Store) Store {
+	for {
+		wrapper, ok := store.(interface{ Unwrap() Store })
+		if !ok {
+			return store
+		}
+		store = wrapper.Unwrap()
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracedStoreSpansNamespaceAndTokenLookups(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previousProvider) })
+
+	ctx := context.Background()
+	store := NewTracedStore(newTestRedisStore(t))
+	if _, err := NewUserNamespace(store).Version(ctx, "jane"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Get(ctx, "auth:jane@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	// A missing version is read, then started
+	if !slices.Equal(names, []string{"cache Get", "cache Incr", "cache Get"}) {
+		t.Fatalf("spans = %v", names)
+	}
+	attributes := recorder.Ended()[2].Attributes()
+	for _, want := range []attribute.KeyValue{attribute.String("cache.key_prefix", "auth"), attribute.Bool("cache.hit", false)} {
+		found := false
+		for _, got := range attributes {
+			found = found || got == want
+		}
+		if !found {
+			t.Errorf("Get span lacks %v: %v", want, attributes)
+		}
+	}
+	for _, got := range attributes {
+		if got.Value.Emit() == "auth:jane@example.com" {
+			t.Errorf("the key is recorded as %s", got.Key)
+		}
+	}
+}
+
+func TestTracedStoreKeepsTheBackendVisible(t *testing.T) {
+	if !Shared(NewTracedStore(newTestRedisStore(t))) {
+		t.Error("a traced Redis store is not shared")
+	}
+	memory, err := NewMemoryStore(MaxCacheSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer memory.Close()
+	if _, ok := Unwrap(NewTracedStore(memory)).(*MemoryStore); !ok {
+		t.Error("Unwrap does not return the memory store")
+	}
+}
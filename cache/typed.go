@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/TimDebug/FitByte/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// Stats holds the hit and miss counters of every Typed cache, keyed by
+// "<name>.hits" and "<name>.misses". It is published as the "cache" expvar.
+var Stats = expvar.NewMap("cache")
+
+// Typed stores values of type T in a Store using the given Codec. The cost of
+// an entry is the size of its encoded value.
+type Typed[T any] struct {
+	name  string
+	store Store
+	codec Codec
+	group singleflight.Group
+}
+
+// NewTyped creates a typed cache. The name is used to report its hits and
+// misses in Stats and in metrics.CacheRequests.
+func NewTyped[T any](name string, store Store, codec Codec) *Typed[T] {
+	return &Typed[T]{name: name, store: store, codec: codec}
+}
+
+// Get returns the cached value. A value that cannot be decoded is reported as
+// an error and treated as a miss.
+func (t *Typed[T]) Get(ctx context.Context, key string) (value T, found bool, err error) {
+	raw, found, err := t.store.Get(ctx, key)
+	if err != nil || !found {
+		t.record(false)
+		return value, false, err
+	}
+
+	if err := t.codec.Unmarshal([]byte(raw), &value); err != nil {
+		t.record(false)
+		return value, false, fmt.Errorf("cache: decode %q: %w", key, err)
+	}
+	t.record(true)
+	return value, true, nil
+}
+
+// record counts a lookup in Stats and in the Prometheus metrics.
+func (t *Typed[T]) record(hit bool) {
+	if hit {
+		Stats.Add(t.name+".hits", 1)
+		metrics.CacheRequests.WithLabelValues(t.name, "hit").Inc()
+		return
+	}
+	Stats.Add(t.name+".misses", 1)
+	metrics.CacheRequests.WithLabelValues(t.name, "miss").Inc()
+}
+
+func (t *Typed[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("cache: encode %q: %w", key, err)
+	}
+	return t.store.Set(ctx, key, string(data), ttl)
+}
+
+func (t *Typed[T]) Delete(ctx context.Context, keys ...string) error {
+	return t.store.Delete(ctx, keys...)
+}
+
+// loadTimeout bounds a shared load, which no longer ends with the
+// context of the caller that started it.
+const loadTimeout = 30 * time.Second
+
+// GetOrLoad returns the cached value or calls load and caches its result.
+// Concurrent misses on the same key share a single load call, which runs
+// detached from the cancellation of any one caller, a caller that gives up
+// only stops waiting. Cache errors never fail the call, only errors from
+// load do.
+func (t *Typed[T]) GetOrLoad(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	load func(ctx context.Context) (T, error),
+) (T, error) {
+	if value, found, err := t.Get(ctx, key); err == nil && found {
+		return value, nil
+	}
+
+	results := t.group.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		value, err := load(ctx)
+		if err != nil {
+			return value, err
+		}
+		// A failed write only means the next call loads again
+		_ = t.Set(ctx, key, value, ttl)
+		return value, nil
+	})
+
+	var zero T
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return zero, result.Err
+		}
+		return result.Val.(T), nil
+	}
+}
@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+type typedTestProfile struct {
+	Email  string
+	Name   *string
+	Weight int
+}
+
+func newTestRedisStore(t *testing.T) *RedisStore {
+	t.Helper()
+	server := miniredis.RunT(t)
+	store, err := NewRedisStore(server.Addr(), "", 0)
+	if err != nil {
+		t.Fatalf("NewRedisStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestTypedRoundTripPerCodec(t *testing.T) {
+	ctx := context.Background()
+	store := newTestRedisStore(t)
+	name := "Jane"
+	want := typedTestProfile{Email: "jane@example.com", Name: &name, Weight: 60}
+
+	codecs := map[string]Codec{"json": JSONCodec, "gob": GobCodec, "msgpack": MsgpackCodec}
+	for codecName, codec := range codecs {
+		t.Run(codecName, func(t *testing.T) {
+			typed := NewTyped[typedTestProfile]("test_profile", store, codec)
+			key := "profile:" + codecName
+
+			if err := typed.Set(ctx, key, want, time.Minute); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			got, found, err := typed.Get(ctx, key)
+			if err != nil || !found {
+				t.Fatalf("Get = found %v, err %v", found, err)
+			}
+			if got.Email != want.Email || got.Name == nil || *got.Name != name || got.Weight != want.Weight {
+				t.Fatalf("Get = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTypedGetReturnsErrorOnMalformedValue(t *testing.T) {
+	ctx := context.Background()
+	store := newTestRedisStore(t)
+	if err := store.Set(ctx, "broken", "{not json", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	typed := NewTyped[[]map[string]string]("test_broken", store, JSONCodec)
+	_, found, err := typed.Get(ctx, "broken")
+	if err == nil || found {
+		t.Fatalf("Get = found %v, err %v; want a decode error", found, err)
+	}
+}
+
+func TestTypedGetOrLoadSharesConcurrentLoads(t *testing.T) {
+	ctx := context.Background()
+	typed := NewTyped[int]("test_answer", newTestRedisStore(t), JSONCodec)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for idx := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := typed.GetOrLoad(ctx, "answer", time.Minute, load)
+			if err != nil {
+				t.Errorf("GetOrLoad: %v", err)
+			}
+			results[idx] = value
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("load called %d times, want 1", calls.Load())
+	}
+	for _, value := range results {
+		if value != 42 {
+			t.Fatalf("GetOrLoad returned %d, want 42", value)
+		}
+	}
+
+	// The value is cached now, load must not run again
+	if _, err := typed.GetOrLoad(ctx, "answer", time.Minute, load); err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("load called again for a cached key")
+	}
+}
+
+func TestTypedGetOrLoadDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	typed := NewTyped[string]("test_error", newTestRedisStore(t), JSONCodec)
+	errLoad := errors.New("database is down")
+
+	_, err := typed.GetOrLoad(ctx, "key", time.Minute, func(ctx context.Context) (string, error) {
+		return "", errLoad
+	})
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("GetOrLoad err = %v, want %v", err, errLoad)
+	}
+	if _, found, _ := typed.Get(ctx, "key"); found {
+		t.Fatalf("failed load must not be cached")
+	}
+}
+
+func TestTypedGetOrLoadSurvivesCancelledFirstCaller(t *testing.T) {
+	typed := NewTyped[int]("test_cancel", newTestRedisStore(t), JSONCodec)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := typed.GetOrLoad(first, "answer", time.Minute, load)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan int, 1)
+	go func() {
+		value, err := typed.GetOrLoad(context.Background(), "answer", time.Minute, load)
+		if err != nil {
+			t.Errorf("second caller: %v", err)
+		}
+		second <- value
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller err = %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	if value := <-second; value != 42 {
+		t.Fatalf("second caller got %d, want 42", value)
+	}
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// Run dispatches a fitbyte subcommand, e.g. `fitbyte gc --dry-run`.
+func Run(args []string) error {
+	switch args[0] {
+	case "gc":
+		return FileGC(args[1:])
+	case "config":
+		return PrintConfig(args[1:])
+	case "migrate":
+		return Migrate(args[1:])
+	case "partitions":
+		return Partitions(args[1:])
+	case "seed":
+		return Seed(args[1:])
+	case "help", "-h", "--help":
+		usage()
+		return nil
+	default:
+		usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: fitbyte [command]
+
+Without a command the HTTP server is started.
+
+Commands:
+  gc          delete uploaded files that are no longer referenced
+  config      print the effective configuration, secrets are redacted
+  migrate     apply, revert or inspect database migrations
+  partitions  create upcoming and drop expired activity partitions
+  seed        generate demo users and activities`)
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/TimDebug/FitByte/config"
+)
+
+// PrintConfig prints the effective configuration with secrets redacted.
+func PrintConfig(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	fmt.Println(cfg)
+	return nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/di"
+	"github.com/TimDebug/FitByte/service"
+	"github.com/samber/do/v2"
+)
+
+func FileGC(args []string) error {
+	appConfig, err := do.Invoke[*config.Config](di.Injector)
+	if err != nil {
+		return err
+	}
+	cfg := appConfig.FileGC
+
+	flags := flag.NewFlagSet("gc", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", cfg.DryRun, "only report orphaned files")
+	minAge := flags.Duration("min-age", cfg.MinAge, "skip files younger than this")
+	prefix := flags.String("prefix", cfg.Prefix, "only sweep keys with this prefix")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	defer di.Injector.Shutdown()
+
+	gc := do.MustInvoke[*service.FileGCService](di.Injector)
+	report, err := gc.Sweep(context.Background(), service.FileSweepOptions{
+		MinAge: *minAge,
+		DryRun: *dryRun,
+		Prefix: *prefix,
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/database"
+	"github.com/TimDebug/FitByte/infrastructure/migration"
+)
+
+// Migrate runs `fitbyte migrate up|down [N]|status|force V|create NAME`.
+func Migrate(args []string) error {
+	if len(args) == 0 {
+		migrateUsage()
+		return errors.New("missing migrate command")
+	}
+
+	if args[0] == "create" {
+		// Writes into the source tree, no database needed
+		if len(args) != 2 {
+			return errors.New("usage: fitbyte migrate create NAME")
+		}
+		paths, err := migration.Create(database.MigrationsDir, args[1])
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	m, err := migration.New(cfg.Database, cfg.Migration)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		if err := m.Up(); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		if err := m.Down(steps); err != nil {
+			return err
+		}
+	case "force":
+		if len(args) != 2 {
+			return errors.New("usage: fitbyte migrate force VERSION")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := m.Force(version); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		migrateUsage()
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	return printMigrationStatus(m)
+}
+
+func printMigrationStatus(m *migration.Migrator) error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	latest, err := migration.LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	state := "up to date"
+	switch {
+	case dirty:
+		state = "dirty, repair it and run `fitbyte migrate force VERSION`"
+	case version < latest:
+		state = fmt.Sprintf("%d pending", latest-version)
+	}
+	fmt.Printf("version %d of %d, %s\n", version, latest, state)
+	return nil
+}
+
+func migrateUsage() {
+	fmt.Fprintln(os.Stderr, `Usage: fitbyte migrate <command>
+
+Commands:
+  up           apply every pending migration
+  down [N]     revert the last N migrations, 1 by default
+  status       print the applied and the latest version
+  force V      mark version V as applied and clean, after a manual repair
+  create NAME  add empty up and down files to `+database.MigrationsDir)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/di"
+	"github.com/TimDebug/FitByte/service"
+	"github.com/samber/do/v2"
+)
+
+func Partitions(args []string) error {
+	appConfig, err := do.Invoke[*config.Config](di.Injector)
+	if err != nil {
+		return err
+	}
+	opts := service.NewPartitionOptions(appConfig.Partition)
+
+	flags := flag.NewFlagSet("partitions", flag.ExitOnError)
+	flags.IntVar(&opts.Premake, "premake", opts.Premake, "create partitions this many months ahead")
+	flags.IntVar(&opts.RetentionMonths, "retention", opts.RetentionMonths, "drop partitions older than this many months, 0 keeps all")
+	flags.BoolVar(&opts.Archive, "archive", opts.Archive, "upload dropped partitions to the storage first")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	defer di.Injector.Shutdown()
+
+	partitions := do.MustInvoke[*service.ActivityPartitionService](di.Injector)
+	report, err := partitions.Maintain(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/database"
+	"github.com/TimDebug/FitByte/di"
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/entity"
+	"github.com/TimDebug/FitByte/repository"
+	"github.com/TimDebug/FitByte/seed"
+	"github.com/samber/do/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// seedBatchUsers is the number of users, with their activities, per COPY.
+const seedBatchUsers = 500
+
+func Seed(args []string) error {
+	opts := seed.DefaultOptions()
+	mix := ""
+	password := "password123"
+	until := opts.Now.Format(time.DateOnly)
+
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	flags.Uint64Var(&opts.Seed, "seed", opts.Seed, "random seed, the same seed generates the same data")
+	flags.IntVar(&opts.Users, "users", opts.Users, "number of users to create")
+	flags.IntVar(&opts.Months, "months", opts.Months, "months of activity history per user")
+	flags.Float64Var(&opts.DaysPerWeek, "days-per-week", opts.DaysPerWeek, "mean active days per week")
+	flags.Float64Var(&opts.Streak, "streak", opts.Streak, "mean number of consecutive active days")
+	flags.StringVar(&mix, "mix", mix, "activity weights, e.g. Walking=3,Running=1 (default all types)")
+	flags.StringVar(&password, "password", password, "password of every seeded user")
+	flags.StringVar(&until, "until", until, "last day of the history, YYYY-MM-DD in UTC (default today)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	now, err := time.Parse(time.DateOnly, until)
+	if err != nil {
+		return fmt.Errorf("seed: until: %w", err)
+	}
+	opts.Now = now
+	if mix != "" {
+		parsed, err := seed.ParseMix(mix)
+		if err != nil {
+			return err
+		}
+		opts.Mix = parsed
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+	opts.PasswordHash = string(hash)
+
+	generator, err := seed.NewGenerator(opts)
+	if err != nil {
+		return err
+	}
+
+	defer di.Injector.Shutdown()
+
+	ctx := context.Background()
+	report := dto.SeedReport{Seed: opts.Seed, Until: until, Password: password, Partitions: []string{}}
+
+	// Rows of months without a partition would pile up in the default one
+	partitions := do.MustInvoke[repository.ActivityPartitionRepository](di.Injector)
+	start := opts.Now.UTC().AddDate(0, -opts.Months, 0)
+	for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(opts.Now); month = month.AddDate(0, 1, 0) {
+		partition, err := partitions.Create(ctx, month)
+		if err != nil {
+			return err
+		}
+		report.Partitions = append(report.Partitions, partition.Name)
+	}
+
+	repo := do.MustInvoke[repository.SeedRepository](di.Injector)
+	tx := do.MustInvoke[*database.TxManager](di.Injector)
+	var userIds []string
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		report.Users, report.Activities = 0, 0
+		userIds = userIds[:0]
+		for first := 0; first < opts.Users; first += seedBatchUsers {
+			last := min(first+seedBatchUsers, opts.Users)
+			users := make([]entity.User, 0, last-first)
+			var activities []entity.Activity
+			for n := first; n < last; n++ {
+				user := generator.User(n)
+				users = append(users, user)
+				userIds = append(userIds, *user.Id)
+				activities = append(activities, generator.Activities(n, user)...)
+			}
+
+			copied, err := repo.CopyUsers(ctx, users)
+			if err != nil {
+				return err
+			}
+			report.Users += copied
+			copied, err = repo.CopyActivities(ctx, activities)
+			if err != nil {
+				return err
+			}
+			report.Activities += copied
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// A running API may have cached lookups of these users, a shared cache
+	// is told they changed. A memory cache lives in the API process, which
+	// must be restarted instead.
+	store := do.MustInvoke[cache.Store](di.Injector)
+	if cache.Shared(store) {
+		namespace := cache.NewUserNamespace(store)
+		for _, id := range userIds {
+			if err := namespace.Bump(ctx, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
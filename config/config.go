@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TimDebug/FitByte/secret"
+	"github.com/joho/godotenv"
+	"github.com/samber/do/v2"
+)
+
+const (
+	ModeDebug      = "DEBUG"
+	ModeProduction = "PRODUCTION"
+)
+
+// Config is the whole application configuration. Every leaf field is read
+// from the `default` tag, then the config file (dotted `config` path), then
+// the `env` variable, the last one found wins. secret.Value fields may hold a
+// reference such as "file:///run/secrets/jwt" that is resolved on load.
+type Config struct {
+	Server    ServerConfig    `config:"server"`
+	Database  DatabaseConfig  `config:"database"`
+	Auth      AuthConfig      `config:"auth"`
+	AWS       AWSConfig       `config:"aws"`
+	Cache     CacheConfig     `config:"cache"`
+	Activity  ActivityConfig  `config:"activity"`
+	FileGC    FileGCConfig    `config:"fileGc"`
+	Partition PartitionConfig `config:"partition"`
+	Migration MigrationConfig `config:"migration"`
+	Metrics   MetricsConfig   `config:"metrics"`
+	Tracing   TracingConfig   `config:"tracing"`
+	Log       LogConfig       `config:"log"`
+	Runtime   RuntimeConfig   `config:"runtime"`
+}
+
+type ServerConfig struct {
+	Mode        string `config:"mode" env:"MODE" default:"DEBUG" validate:"oneof=DEBUG PRODUCTION"`
+	Port        int    `config:"port" env:"PORT" default:"8080" validate:"min=1,max=65535"`
+	ProdHost    string `config:"prodHost" env:"PROD_HOST"`
+	DebugHost   string `config:"debugHost" env:"DEBUG_HOST"`
+	SSLCertPath string `config:"sslCertPath" env:"SSL_CERT_PATH" validate:"required_if=Mode PRODUCTION"`
+	SSLKeyPath  string `config:"sslKeyPath" env:"SSL_KEY_PATH" validate:"required_if=Mode PRODUCTION"`
+	// HealthCheckTimeout bounds every dependency check of /readyz.
+	HealthCheckTimeout time.Duration `config:"healthCheckTimeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s" validate:"min=0"`
+	// ShutdownDelay is how long readiness reports failing before the server
+	// stops accepting connections, so load balancers stop routing to it.
+	ShutdownDelay time.Duration `config:"shutdownDelay" env:"SHUTDOWN_DELAY" default:"0s" validate:"min=0"`
+	// ShutdownTimeout bounds draining in-flight requests, counted from the end
+	// of ShutdownDelay, and then again closing the pool, cache and workers.
+	ShutdownTimeout time.Duration `config:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" default:"30s" validate:"min=0"`
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For names
+	// the client. Without any the client is the peer of the connection.
+	TrustedProxies []string `config:"trustedProxies" env:"TRUSTED_PROXIES" validate:"dive,cidr|ip"`
+}
+
+// Addr is the address the HTTP server listens on for the current mode.
+func (s ServerConfig) Addr() string {
+	host := s.DebugHost
+	if s.Mode == ModeProduction {
+		host = s.ProdHost
+	}
+	return fmt.Sprintf("%s:%d", host, s.Port)
+}
+
+type AuthConfig struct {
+	JWTSecret secret.Value `config:"jwtSecret" env:"JWT_SECRET_KEY" validate:"required"`
+}
+
+type AWSConfig struct {
+	AccessKeyID     secret.Value `config:"accessKeyId" env:"AWS_ACCESS_KEY_ID"`
+	SecretAccessKey secret.Value `config:"secretAccessKey" env:"AWS_SECRET_ACCESS_KEY"`
+	Region          string       `config:"region" env:"AWS_REGION"`
+	Bucket          string       `config:"bucket" env:"AWS_BUCKET"`
+}
+
+type MigrationConfig struct {
+	// AutoMigrate applies pending migrations when the server starts.
+	AutoMigrate bool `config:"autoMigrate" env:"ENABLE_AUTO_MIGRATE" default:"false"`
+	// LockTimeout is how long a replica waits for another one to finish
+	// migrating before it gives up.
+	LockTimeout time.Duration `config:"lockTimeout" env:"MIGRATION_LOCK_TIMEOUT" default:"5m" validate:"min=0"`
+}
+
+type MetricsConfig struct {
+	Enabled bool `config:"enabled" env:"METRICS_ENABLED" default:"true"`
+	// Addr is the admin listener serving /metrics and the operator
+	// endpoints, never the API port. Empty disables it.
+	Addr string `config:"addr" env:"METRICS_ADDR" default:"localhost:9090"`
+}
+
+// Load reads .env, the config file named by CONFIG_FILE (or config.yaml,
+// config.yml, config.toml in the working directory) and the environment.
+// The returned error lists every missing or invalid value.
+func Load() (*Config, error) {
+	_ = godotenv.Load()
+
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		// The token itself may live in a file, e.g. file:///run/secrets/vault_token
+		token, err := secret.Resolve(context.Background(), os.Getenv("VAULT_TOKEN"))
+		if err != nil {
+			return nil, fmt.Errorf("VAULT_TOKEN: %w", err)
+		}
+		secret.RegisterProvider("vault", secret.NewVaultProvider(addr, token))
+	}
+
+	return LoadFile(FilePath())
+}
+
+// FilePath returns the config file Load reads, or "" when there is none.
+func FilePath() string {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	for _, candidate := range []string{"config.yaml", "config.yml", "config.toml"} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// LoadFile is Load with an explicit config file, an empty path skips the file.
+func LoadFile(path string) (*Config, error) {
+	cfg := &Config{}
+	if err := newLoader(cfg).load(path, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Dump lists every effective setting as "key = value ($ENV)", sorted by key.
+// Secrets are printed as [REDACTED].
+func (c *Config) Dump() []string {
+	l := newLoader(c)
+	lines := make([]string, 0, len(l.fields))
+	for _, f := range l.fields {
+		lines = append(lines, fmt.Sprintf("%s = %v%s", f.key, f.value.Interface(), describeEnv(f.env)))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// String implements fmt.Stringer so that printing a Config never leaks secrets.
+func (c *Config) String() string {
+	return strings.Join(c.Dump(), "\n")
+}
+
+func NewConfigInject(i do.Injector) (*Config, error) {
+	return Load()
+}
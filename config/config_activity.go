@@ -0,0 +1,6 @@
+package config
+
+type ActivityConfig struct {
+	// BatchMaxItems caps the activities of one POST /v1/activity/batch.
+	BatchMaxItems int `config:"batchMaxItems" env:"ACTIVITY_BATCH_MAX_ITEMS" default:"100" validate:"min=1,max=1000"`
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"time"
+
+	"github.com/TimDebug/FitByte/secret"
+)
+
+const (
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
+
+type CacheConfig struct {
+	// Backend is either "memory" (per-instance ristretto) or "redis" (shared).
+	Backend       string       `config:"backend" env:"CACHE_BACKEND" default:"memory" validate:"oneof=memory redis"`
+	RedisAddr     string       `config:"redisAddr" env:"REDIS_ADDR" default:"localhost:6379" validate:"required_if=Backend redis"`
+	RedisPassword secret.Value `config:"redisPassword" env:"REDIS_PASSWORD"`
+	RedisDB       int          `config:"redisDb" env:"REDIS_DB" default:"0" validate:"min=0"`
+	// HttpStoreResponses keeps whole GET responses in the cache, not only
+	// their ETag. It needs the redis backend, with the memory backend the
+	// ETag is computed over the body of every response.
+	HttpStoreResponses bool          `config:"httpStoreResponses" env:"HTTP_CACHE_STORE_RESPONSES" default:"false"`
+	HttpResponseTtl    time.Duration `config:"httpResponseTtl" env:"HTTP_CACHE_TTL" default:"1m" validate:"min=0"`
+}
@@ -2,67 +2,92 @@ package config
 
 import (
 	"fmt"
-	"log"
-	"os"
-	"strings"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/TimDebug/FitByte/secret"
 )
 
-type Config struct {
-	DatabaseURL string
-	Port        string
+type DatabaseConfig struct {
+	User     string       `config:"user" env:"POSTGRES_USER" default:"postgres" validate:"required"`
+	Password secret.Value `config:"password" env:"POSTGRES_PASSWORD"`
+	Host     string       `config:"host" env:"POSTGRES_HOST" default:"localhost" validate:"required"`
+	Port     int          `config:"port" env:"POSTGRES_PORT" default:"5432" validate:"min=1,max=65535"`
+	Name     string       `config:"name" env:"POSTGRES_DB" default:"postgres" validate:"required"`
+	// TxIsolation is the default isolation level of TxManager.WithinTx.
+	TxIsolation  string        `config:"txIsolation" env:"DB_TX_ISOLATION" default:"read committed" validate:"oneof='read committed' 'repeatable read' serializable"`
+	TxMaxRetries int           `config:"txMaxRetries" env:"DB_TX_MAX_RETRIES" default:"3" validate:"min=0"`
+	Pool         PoolConfig    `config:"pool"`
+	Replicas     ReplicaConfig `config:"replicas"`
 }
 
-func LoadConfig() *Config {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-
-	dbUser := getEnv("POSTGRES_USER", "postgres")
-	dbPassword := getEnv("POSTGRES_PASSWORD", "")
-	dbHost := getEnv("POSTGRES_HOST", "localhost")
-	dbPort := getEnv("POSTGRES_PORT", "5432")
-	dbName := getEnv("POSTGRES_DB", "postgres")
-	port := getEnv("PORT", "8080")
-
-	databaseURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
-		dbUser, dbPassword, dbHost, dbPort, dbName,
-	)
+// PoolConfig tunes the single pgx pool of the application. MaxConns is per
+// instance, keep MaxConns times the number of replicas below the server's
+// max_connections.
+type PoolConfig struct {
+	MaxConns          int           `config:"maxConns" env:"DB_MAX_CONNS" default:"10" validate:"min=1"`
+	MinConns          int           `config:"minConns" env:"DB_MIN_CONNS" default:"0" validate:"min=0,ltefield=MaxConns"`
+	MaxConnLifetime   time.Duration `config:"maxConnLifetime" env:"DB_MAX_CONN_LIFETIME" default:"1h" validate:"min=0"`
+	MaxConnIdleTime   time.Duration `config:"maxConnIdleTime" env:"DB_MAX_CONN_IDLE_TIME" default:"30m" validate:"min=0"`
+	HealthCheckPeriod time.Duration `config:"healthCheckPeriod" env:"DB_HEALTH_CHECK_PERIOD" default:"1m" validate:"min=0"`
+	ConnectTimeout    time.Duration `config:"connectTimeout" env:"DB_CONNECT_TIMEOUT" default:"5s" validate:"min=0"`
+	// StatementTimeout is set as statement_timeout on every connection, the
+	// server cancels longer queries. 0 disables it.
+	StatementTimeout time.Duration `config:"statementTimeout" env:"DB_STATEMENT_TIMEOUT" default:"30s" validate:"min=0"`
+	ApplicationName  string        `config:"applicationName" env:"DB_APPLICATION_NAME" default:"fitbyte"`
+	// ExecMode is pgx's query exec mode. Behind PgBouncer in transaction
+	// mode use "exec" or "simple_protocol", prepared statements do not
+	// survive switching server connections.
+	ExecMode               string `config:"execMode" env:"DB_EXEC_MODE" default:"cache_statement" validate:"oneof=cache_statement cache_describe describe_exec exec simple_protocol"`
+	StatementCacheCapacity int    `config:"statementCacheCapacity" env:"DB_STATEMENT_CACHE_CAPACITY" default:"512" validate:"min=0"`
+}
 
-	return &Config{
-		DatabaseURL: databaseURL,
-		Port:        port,
-	}
+// ReplicaConfig routes read-only queries to streaming replicas. Without
+// hosts every query goes to the primary.
+type ReplicaConfig struct {
+	// Hosts are "host" or "host:port" of the replicas, they share the user,
+	// password and database name of the primary.
+	Hosts []string `config:"hosts" env:"DB_REPLICA_HOSTS"`
+	// MaxLag excludes replicas further behind the primary until they catch up.
+	MaxLag         time.Duration `config:"maxLag" env:"DB_REPLICA_MAX_LAG" default:"5s" validate:"min=0"`
+	LagCheckPeriod time.Duration `config:"lagCheckPeriod" env:"DB_REPLICA_LAG_CHECK_PERIOD" default:"2s" validate:"gt=0"`
+	// ReadYourWrites sends a user's reads to the primary for this long after
+	// the user wrote, so they see their own changes. 0 disables it.
+	ReadYourWrites time.Duration `config:"readYourWrites" env:"DB_READ_YOUR_WRITES" default:"5s" validate:"min=0"`
 }
 
-func DatabaseMigrateUrl() string {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("No .env file found, using environment variables")
+// Replica returns the settings of the primary pointed at host, a "host" or
+// "host:port" of ReplicaConfig.Hosts.
+func (d DatabaseConfig) Replica(host string) (DatabaseConfig, error) {
+	replica := d
+	replica.Host = host
+	if name, port, err := net.SplitHostPort(host); err == nil {
+		replica.Host = name
+		if replica.Port, err = strconv.Atoi(port); err != nil {
+			return DatabaseConfig{}, fmt.Errorf("replica %q: invalid port", host)
+		}
 	}
+	return replica, nil
+}
 
-	dbUser := getEnv("POSTGRES_USER", "postgres")
-	dbPassword := getEnv("POSTGRES_PASSWORD", "")
-	dbHost := getEnv("POSTGRES_HOST", "localhost")
-	dbPort := getEnv("POSTGRES_PORT", "5432")
-	dbName := getEnv("POSTGRES_DB", "postgres")
-
-	databaseURL := fmt.Sprintf("pgx://%s:%s@%s:%s/%s",
-		dbUser, dbPassword, dbHost, dbPort, dbName,
-	)
-
-	return databaseURL
+// URL is the connection string used by pgx.
+func (d DatabaseConfig) URL() string {
+	return d.url("postgres")
 }
 
-func EnableAutoMigrate() bool {
-	return strings.ToUpper(getEnv("ENABLE_AUTO_MIGRATE", "FALSE")) == "TRUE"
+// MigrateURL is the connection string used by golang-migrate's pgx driver.
+func (d DatabaseConfig) MigrateURL() string {
+	return d.url("pgx")
 }
 
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+func (d DatabaseConfig) url(scheme string) string {
+	u := url.URL{
+		Scheme: scheme,
+		User:   url.UserPassword(d.User, d.Password.Reveal()),
+		Host:   fmt.Sprintf("%s:%d", d.Host, d.Port),
+		Path:   d.Name,
 	}
-	return fallback
+	return u.String()
 }
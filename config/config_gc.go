@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+type FileGCConfig struct {
+	// Enabled turns on the periodic sweeper started together with the server.
+	Enabled bool `config:"enabled" env:"FILE_GC_ENABLED" default:"false"`
+	// Interval is the time between two scheduled sweeps.
+	Interval time.Duration `config:"interval" env:"FILE_GC_INTERVAL" default:"24h" validate:"gt=0"`
+	// MinAge protects freshly uploaded files that are not referenced yet.
+	MinAge time.Duration `config:"minAge" env:"FILE_GC_MIN_AGE" default:"24h" validate:"min=0"`
+	// DryRun reports orphaned files without deleting them.
+	DryRun bool `config:"dryRun" env:"FILE_GC_DRY_RUN" default:"false"`
+	// Prefix limits the sweep to keys starting with it.
+	Prefix string `config:"prefix" env:"FILE_GC_PREFIX"`
+}
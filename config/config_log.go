@@ -0,0 +1,60 @@
+package config
+
+import "time"
+
+const (
+	LogFormatJson    = "json"
+	LogFormatConsole = "console"
+
+	LogSinkStdout = "stdout"
+	LogSinkFile   = "file"
+	LogSinkSyslog = "syslog"
+	LogSinkHttp   = "http"
+)
+
+// LogConfig configures where entries go and how they look, the global level
+// is runtime.logLevel so it can change without a restart.
+type LogConfig struct {
+	Format string   `config:"format" env:"LOG_FORMAT" default:"json" validate:"oneof=json console"`
+	Sinks  []string `config:"sinks" env:"LOG_SINKS" default:"file" validate:"min=1,dive,oneof=stdout file syslog http"`
+	// PackageLevels override the global level for entries logged from a
+	// package, e.g. "service=debug,middleware=warn".
+	PackageLevels []string      `config:"packageLevels" env:"LOG_PACKAGE_LEVELS"`
+	File          LogFileConfig `config:"file"`
+	Syslog        SyslogConfig  `config:"syslog"`
+	Http          LogHttpConfig `config:"http"`
+	// SampleInitial entries per second with the same level and message are
+	// kept, then only every SampleThereafter-th one. 0 disables sampling.
+	SampleInitial    int `config:"sampleInitial" env:"LOG_SAMPLE_INITIAL" default:"0" validate:"min=0"`
+	SampleThereafter int `config:"sampleThereafter" env:"LOG_SAMPLE_THEREAFTER" default:"100" validate:"min=1"`
+
+	// RedactKeys are masked in addition to password, token, authorization
+	// and secret, matched case-insensitively against field and map keys.
+	RedactKeys []string `config:"redactKeys" env:"LOG_REDACT_KEYS"`
+	// RedactPatterns are regular expressions whose matches are masked in
+	// every logged string, e.g. card or phone numbers.
+	RedactPatterns []string `config:"redactPatterns" env:"LOG_REDACT_PATTERNS"`
+}
+
+type LogFileConfig struct {
+	Path       string `config:"path" env:"LOG_FILE" default:"./logs/app.log" validate:"required"`
+	MaxSizeMB  int    `config:"maxSizeMb" env:"LOG_FILE_MAX_SIZE_MB" default:"10" validate:"min=1"`
+	MaxBackups int    `config:"maxBackups" env:"LOG_FILE_MAX_BACKUPS" default:"10" validate:"min=0"`
+	MaxAgeDays int    `config:"maxAgeDays" env:"LOG_FILE_MAX_AGE_DAYS" default:"30" validate:"min=0"`
+	Compress   bool   `config:"compress" env:"LOG_FILE_COMPRESS" default:"true"`
+}
+
+type SyslogConfig struct {
+	// Network and Addr of the syslog daemon, e.g. udp and logs.local:514,
+	// both empty use the local daemon.
+	Network string `config:"network" env:"LOG_SYSLOG_NETWORK"`
+	Addr    string `config:"addr" env:"LOG_SYSLOG_ADDR"`
+	Tag     string `config:"tag" env:"LOG_SYSLOG_TAG" default:"fitbyte"`
+}
+
+// LogHttpConfig ships entries as newline-delimited JSON in POST requests.
+type LogHttpConfig struct {
+	URL           string        `config:"url" env:"LOG_HTTP_URL"`
+	BatchSize     int           `config:"batchSize" env:"LOG_HTTP_BATCH_SIZE" default:"100" validate:"min=1"`
+	FlushInterval time.Duration `config:"flushInterval" env:"LOG_HTTP_FLUSH_INTERVAL" default:"5s" validate:"min=0"`
+}
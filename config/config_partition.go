@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+type PartitionConfig struct {
+	// Enabled creates future partitions of activities, and drops expired
+	// ones, in the background of the server. Without it rows of months
+	// without a partition end up in activities_default.
+	Enabled bool `config:"enabled" env:"PARTITION_MAINTENANCE_ENABLED" default:"true"`
+	// Interval is the time between two maintenance runs, the first one runs
+	// at start.
+	Interval time.Duration `config:"interval" env:"PARTITION_MAINTENANCE_INTERVAL" default:"24h" validate:"gt=0"`
+	// Premake is how many months after the current one get a partition.
+	Premake int `config:"premake" env:"PARTITION_PREMAKE_MONTHS" default:"3" validate:"min=1"`
+	// RetentionMonths drops partitions of months this far before the current
+	// one, 0 keeps every activity. Rows of an expired month written after its
+	// partition was dropped land in activities_default, they are moved to a
+	// new partition and dropped by the same run.
+	RetentionMonths int `config:"retentionMonths" env:"ACTIVITY_RETENTION_MONTHS" default:"0" validate:"min=0"`
+	// Archive uploads a partition as gzipped CSV to the storage before it is
+	// dropped. A month archived again gets a numbered archive, -2 and on.
+	Archive       bool   `config:"archive" env:"ACTIVITY_ARCHIVE" default:"false"`
+	ArchivePrefix string `config:"archivePrefix" env:"ACTIVITY_ARCHIVE_PREFIX" default:"archive/activities/"`
+}
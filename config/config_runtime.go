@@ -0,0 +1,24 @@
+package config
+
+import (
+	"slices"
+	"time"
+)
+
+// RuntimeConfig holds the settings that can change without a restart, see
+// Reloader. Everything outside of it needs a restart to take effect.
+type RuntimeConfig struct {
+	LogLevel string `config:"logLevel" env:"LOG_LEVEL" default:"info" validate:"oneof=debug info warn error"`
+	// RateLimitPerSecond is the sustained request rate allowed per client IP,
+	// 0 disables rate limiting.
+	RateLimitPerSecond float64       `config:"rateLimitPerSecond" env:"RATE_LIMIT_PER_SECOND" default:"0" validate:"min=0"`
+	RateLimitBurst     int           `config:"rateLimitBurst" env:"RATE_LIMIT_BURST" default:"20" validate:"min=1"`
+	CORSAllowedOrigins []string      `config:"corsAllowedOrigins" env:"CORS_ALLOWED_ORIGINS" default:"*" validate:"min=1"`
+	CacheTtl           time.Duration `config:"cacheTtl" env:"CACHE_TTL" default:"5m" validate:"min=0"`
+	// FeatureFlags lists the enabled feature flags.
+	FeatureFlags []string `config:"featureFlags" env:"FEATURE_FLAGS"`
+}
+
+func (r RuntimeConfig) FeatureEnabled(flag string) bool {
+	return slices.Contains(r.FeatureFlags, flag)
+}
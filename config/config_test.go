@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFileDefaults(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "secret")
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Server.Port != 8080 || cfg.Server.Mode != ModeDebug {
+		t.Fatalf("server defaults = %+v", cfg.Server)
+	}
+	if cfg.FileGC.MinAge != 24*time.Hour || cfg.Cache.Backend != CacheBackendMemory {
+		t.Fatalf("defaults not applied: %+v %+v", cfg.FileGC, cfg.Cache)
+	}
+	if got := cfg.Database.URL(); got != "postgres://postgres:@localhost:5432/postgres" {
+		t.Fatalf("Database.URL() = %q", got)
+	}
+}
+
+func TestLoadFileYamlWithEnvOverride(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  port: 3000
+  debugHost: 0.0.0.0
+auth:
+  jwtSecret: from-file
+database:
+  host: db
+fileGc:
+  minAge: 48h
+`)
+	t.Setenv("POSTGRES_HOST", "db-from-env")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Server.Addr() != "0.0.0.0:3000" {
+		t.Fatalf("Server.Addr() = %q", cfg.Server.Addr())
+	}
+	if cfg.Auth.JWTSecret != "from-file" || cfg.FileGC.MinAge != 48*time.Hour {
+		t.Fatalf("file values not applied: %+v %+v", cfg.Auth, cfg.FileGC)
+	}
+	if cfg.Database.Host != "db-from-env" {
+		t.Fatalf("Database.Host = %q, env must win over the file", cfg.Database.Host)
+	}
+}
+
+func TestLoadFileToml(t *testing.T) {
+	path := writeConfigFile(t, "config.toml", `
+[auth]
+jwtSecret = "toml-secret"
+
+[cache]
+backend = "redis"
+redisAddr = "redis:6379"
+`)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Auth.JWTSecret != "toml-secret" || cfg.Cache.Backend != CacheBackendRedis || cfg.Cache.RedisAddr != "redis:6379" {
+		t.Fatalf("toml values not applied: %+v %+v", cfg.Auth, cfg.Cache)
+	}
+}
+
+func TestLoadFileReportsEveryProblem(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  mode: PRODUCTION
+  prot: 80
+`)
+	t.Setenv("PORT", "not-a-number")
+	t.Setenv("FILE_GC_MIN_AGE", "tomorrow")
+
+	_, err := LoadFile(path)
+	if err == nil {
+		t.Fatalf("LoadFile succeeded with an invalid configuration")
+	}
+
+	for _, want := range []string{
+		"server.port (from $PORT): invalid integer",
+		"fileGc.minAge (from $FILE_GC_MIN_AGE): invalid duration",
+		"server.prot",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  mode: PRODUCTION
+cache:
+  backend: memcached
+log:
+  sinks: [stdout, http]
+database:
+  pool:
+    maxConns: 4
+    minConns: 8
+`)
+
+	_, err := LoadFile(path)
+	if err == nil {
+		t.Fatalf("LoadFile succeeded with an invalid configuration")
+	}
+
+	for _, want := range []string{
+		"auth.jwtSecret ($JWT_SECRET_KEY): is required",
+		"server.sslCertPath ($SSL_CERT_PATH): is required when Mode is PRODUCTION",
+		"server.sslKeyPath ($SSL_KEY_PATH)",
+		"cache.backend ($CACHE_BACKEND): must be one of [memory redis]",
+		"aws.bucket ($AWS_BUCKET): is required in PRODUCTION mode",
+		"log.http.url ($LOG_HTTP_URL): is required when log.sinks contains http",
+		"database.pool.minConns ($DB_MIN_CONNS): must not exceed MaxConns",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadFileResolvesSecretsAndRedactsDump(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "jwt")
+	if err := os.WriteFile(secretFile, []byte("jwt-from-file\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	t.Setenv("JWT_SECRET_KEY", "file://"+secretFile)
+	t.Setenv("FITBYTE_TEST_DB_PASSWORD", "db-password")
+	t.Setenv("POSTGRES_PASSWORD", "env:FITBYTE_TEST_DB_PASSWORD")
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Auth.JWTSecret.Reveal() != "jwt-from-file" || cfg.Database.Password.Reveal() != "db-password" {
+		t.Fatalf("secrets not resolved")
+	}
+
+	dump := cfg.String()
+	for _, leaked := range []string{"jwt-from-file", "db-password"} {
+		if strings.Contains(dump, leaked) {
+			t.Errorf("config dump leaks %q:\n%s", leaked, dump)
+		}
+	}
+	if !strings.Contains(dump, "auth.jwtSecret = [REDACTED] ($JWT_SECRET_KEY)") {
+		t.Errorf("config dump misses the redacted secret:\n%s", dump)
+	}
+}
+
+func TestLoadFileReportsUnresolvableSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "file:///run/secrets/does-not-exist")
+
+	_, err := LoadFile("")
+	if err == nil || !strings.Contains(err.Error(), "auth.jwtSecret (from $JWT_SECRET_KEY): resolve file secret") {
+		t.Fatalf("LoadFile err = %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+	TracingExporterOtlp   = "otlp"
+)
+
+type TracingConfig struct {
+	// Exporter selects where spans go: none, stdout or file for local use,
+	// otlp for a collector.
+	Exporter    string `config:"exporter" env:"TRACING_EXPORTER" default:"none" validate:"oneof=none stdout file otlp"`
+	ServiceName string `config:"serviceName" env:"OTEL_SERVICE_NAME" default:"fitbyte" validate:"required"`
+	// OtlpEndpoint is the collector's OTLP/HTTP address, e.g. localhost:4318.
+	OtlpEndpoint string `config:"otlpEndpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"localhost:4318" validate:"required_if=Exporter otlp"`
+	OtlpInsecure bool   `config:"otlpInsecure" env:"OTEL_EXPORTER_OTLP_INSECURE" default:"false"`
+	FilePath     string `config:"filePath" env:"TRACING_FILE" default:"./logs/traces.json" validate:"required_if=Exporter file"`
+	// SampleRatio is the share of new traces recorded, a sampled parent
+	// is always followed.
+	SampleRatio float64 `config:"sampleRatio" env:"TRACING_SAMPLE_RATIO" default:"1" validate:"min=0,max=1"`
+}
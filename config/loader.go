@@ -0,0 +1,224 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TimDebug/FitByte/secret"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	secretType   = reflect.TypeOf(secret.Value(""))
+)
+
+// field is a leaf of the Config tree.
+type field struct {
+	key   string // dotted path in the config file, e.g. server.port
+	env   string
+	def   string
+	value reflect.Value
+}
+
+type loader struct {
+	fields []field
+}
+
+func newLoader(cfg *Config) *loader {
+	l := &loader{}
+	l.collect(reflect.ValueOf(cfg).Elem(), "")
+	return l
+}
+
+func (l *loader) collect(v reflect.Value, prefix string) {
+	t := v.Type()
+	for idx := 0; idx < t.NumField(); idx++ {
+		structField := t.Field(idx)
+		key := structField.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		value := v.Field(idx)
+		if value.Kind() == reflect.Struct && value.Type() != durationType {
+			l.collect(value, key)
+			continue
+		}
+		l.fields = append(l.fields, field{
+			key:   key,
+			env:   structField.Tag.Get("env"),
+			def:   structField.Tag.Get("default"),
+			value: value,
+		})
+	}
+}
+
+// envKey returns the environment variable bound to a config key.
+func (l *loader) envKey(key string) string {
+	for _, f := range l.fields {
+		if f.key == key {
+			return f.env
+		}
+	}
+	return ""
+}
+
+// load applies defaults, the file and the environment in that order. Every
+// value that can not be parsed is reported, not only the first one.
+func (l *loader) load(path string, lookupEnv func(string) (string, bool)) error {
+	var errs []error
+
+	fileValues := map[string]string{}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		fileValues = values
+	}
+
+	known := make(map[string]struct{}, len(l.fields))
+	for _, f := range l.fields {
+		known[f.key] = struct{}{}
+
+		raw, source, found := f.def, "default", f.def != ""
+		if value, ok := fileValues[f.key]; ok {
+			raw, source, found = value, path, true
+		}
+		if value, ok := lookupEnv(f.env); ok && f.env != "" {
+			raw, source, found = value, "$"+f.env, true
+		}
+		if !found {
+			continue
+		}
+
+		if f.value.Type() == secretType {
+			resolved, err := secret.Resolve(context.Background(), raw)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s (from %s): %w", f.key, source, err))
+				continue
+			}
+			raw = resolved
+		}
+
+		if err := setValue(f.value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s (from %s): %w", f.key, source, err))
+		}
+	}
+
+	for key := range fileValues {
+		if _, ok := known[key]; !ok {
+			errs = append(errs, fmt.Errorf("%s (from %s): unknown setting", key, path))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	tree := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file %q, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten(tree, "", values)
+	return values, nil
+}
+
+func flatten(tree map[string]any, prefix string, out map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(v, key, out)
+		case []any:
+			items := make([]string, len(v))
+			for idx, item := range v {
+				items[idx] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	if v.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(duration))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		items := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
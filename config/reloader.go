@@ -0,0 +1,178 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/samber/do/v2"
+)
+
+const reloadDebounce = 500 * time.Millisecond
+
+// Reloader re-reads the configuration on SIGHUP or when the config file
+// changes. A new configuration is validated as a whole before anything is
+// applied; then only its Runtime section is published to the subscribers.
+// Changes to any other setting are reported as needing a restart.
+type Reloader struct {
+	path    string
+	load    func() (*Config, error)
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []func(RuntimeConfig)
+
+	stopWatch context.CancelFunc
+	watchDone chan struct{}
+}
+
+func NewReloader(initial *Config, path string, load func() (*Config, error)) *Reloader {
+	r := &Reloader{path: path, load: load}
+	r.current.Store(initial)
+	return r
+}
+
+func NewReloaderInject(i do.Injector) (*Reloader, error) {
+	cfg := do.MustInvoke[*Config](i)
+	return NewReloader(cfg, FilePath(), Load), nil
+}
+
+// Runtime returns the settings currently in effect.
+func (r *Reloader) Runtime() RuntimeConfig {
+	return r.current.Load().Runtime
+}
+
+// Subscribe calls fn with the current runtime settings and again after every
+// reload that changed them.
+func (r *Reloader) Subscribe(fn func(RuntimeConfig)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+	fn(r.Runtime())
+}
+
+// Reload loads and validates the configuration and publishes its runtime
+// settings. It returns the keys of changed settings that need a restart,
+// those keep their old value until then.
+func (r *Reloader) Reload() (restartRequired []string, err error) {
+	next, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.current.Load()
+	restartRequired = changedStaticKeys(previous, next)
+
+	// Keep the static settings that are actually in use
+	applied := *previous
+	applied.Runtime = next.Runtime
+	r.current.Store(&applied)
+
+	if !reflect.DeepEqual(previous.Runtime, next.Runtime) {
+		for _, fn := range r.subscribers {
+			fn(next.Runtime)
+		}
+	}
+	return restartRequired, nil
+}
+
+// Watch reloads on SIGHUP and on writes to the config file until ctx is done.
+func (r *Reloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	if r.path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			log.Printf("Config file watch disabled: %v", err)
+		} else {
+			defer watcher.Close()
+			// Watch the directory, editors and Kubernetes replace the file
+			if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+				log.Printf("Config file watch disabled: %v", err)
+			} else {
+				events = watcher.Events
+			}
+		}
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reloadAndReport("SIGHUP")
+		case event := <-events:
+			if filepath.Clean(event.Name) == filepath.Clean(r.path) &&
+				event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				debounce = time.After(reloadDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			r.reloadAndReport(r.path + " changed")
+		}
+	}
+}
+
+// Start runs Watch in the background until Shutdown.
+func (r *Reloader) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stopWatch = cancel
+	r.watchDone = make(chan struct{})
+	go func() {
+		defer close(r.watchDone)
+		r.Watch(ctx)
+	}()
+}
+
+// Shutdown stops the watcher started by Start when the injector shuts down.
+func (r *Reloader) Shutdown() {
+	if r.stopWatch == nil {
+		return
+	}
+	r.stopWatch()
+	<-r.watchDone
+}
+
+func (r *Reloader) reloadAndReport(reason string) {
+	restartRequired, err := r.Reload()
+	if err != nil {
+		log.Printf("Config reload (%s) rejected, keeping the current settings:\n%v", reason, err)
+		return
+	}
+	log.Printf("Config reloaded (%s)", reason)
+	if len(restartRequired) > 0 {
+		log.Printf("Changed settings that need a restart: %s", strings.Join(restartRequired, ", "))
+	}
+}
+
+func changedStaticKeys(previous, next *Config) []string {
+	before := newLoader(previous).fields
+	after := newLoader(next).fields
+
+	changed := make([]string, 0)
+	for idx, f := range before {
+		if strings.HasPrefix(f.key, "runtime.") {
+			continue
+		}
+		if !reflect.DeepEqual(f.value.Interface(), after[idx].value.Interface()) {
+			changed = append(changed, f.key)
+		}
+	}
+	return changed
+}
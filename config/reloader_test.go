@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReloaderPublishesRuntimeSettings(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "secret")
+	path := writeConfigFile(t, "config.yaml", `
+runtime:
+  logLevel: info
+`)
+	load := func() (*Config, error) { return LoadFile(path) }
+	initial, err := load()
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+
+	reloader := NewReloader(initial, path, load)
+	var published []RuntimeConfig
+	reloader.Subscribe(func(runtime RuntimeConfig) {
+		published = append(published, runtime)
+	})
+
+	err = os.WriteFile(path, []byte(`
+server:
+  port: 9090
+runtime:
+  logLevel: debug
+  cacheTtl: 1m
+  featureFlags: [batch]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	restartRequired, err := reloader.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if !slices.Equal(restartRequired, []string{"server.port"}) {
+		t.Errorf("restartRequired = %v, want [server.port]", restartRequired)
+	}
+	if len(published) != 2 || published[1].LogLevel != "debug" || published[1].CacheTtl != time.Minute {
+		t.Fatalf("published = %+v", published)
+	}
+	if !reloader.Runtime().FeatureEnabled("batch") {
+		t.Errorf("Runtime() = %+v, feature flag not applied", reloader.Runtime())
+	}
+}
+
+func TestReloaderRejectsInvalidConfig(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "secret")
+	path := writeConfigFile(t, "config.yaml", "")
+	load := func() (*Config, error) { return LoadFile(path) }
+	initial, err := load()
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+
+	reloader := NewReloader(initial, path, load)
+	calls := 0
+	reloader.Subscribe(func(RuntimeConfig) { calls++ })
+
+	err = os.WriteFile(path, []byte(`
+runtime:
+  logLevel: verbose
+  rateLimitPerSecond: 5
+`), 0o600)
+	if err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	if _, err := reloader.Reload(); err == nil {
+		t.Fatalf("Reload accepted an invalid log level")
+	}
+	if calls != 1 || reloader.Runtime().RateLimitPerSecond != 0 {
+		t.Fatalf("invalid config was applied: calls=%d runtime=%+v", calls, reloader.Runtime())
+	}
+}
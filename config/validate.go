@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = func() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("config")
+	})
+	return v
+}()
+
+// Validate checks every setting and returns all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	envKeys := newLoader(c)
+
+	if err := validate.Struct(c); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return err
+		}
+		for _, fieldError := range validationErrors {
+			// Namespace is "Config.server.port", drop the root type
+			key := strings.TrimPrefix(fieldError.Namespace(), "Config.")
+			errs = append(errs, fmt.Errorf("%s%s: %s", key, describeEnv(envKeys.envKey(key)), describeRule(fieldError)))
+		}
+	}
+
+	if c.Server.Mode == ModeProduction {
+		// S3 is only used outside of DEBUG mode
+		for _, setting := range []struct{ key, value string }{
+			{"aws.accessKeyId", c.AWS.AccessKeyID.Reveal()},
+			{"aws.secretAccessKey", c.AWS.SecretAccessKey.Reveal()},
+			{"aws.region", c.AWS.Region},
+			{"aws.bucket", c.AWS.Bucket},
+		} {
+			if setting.value == "" {
+				errs = append(errs, fmt.Errorf("%s%s: is required in PRODUCTION mode", setting.key, describeEnv(envKeys.envKey(setting.key))))
+			}
+		}
+	}
+
+	if slices.Contains(c.Log.Sinks, LogSinkHttp) && c.Log.Http.URL == "" {
+		errs = append(errs, fmt.Errorf("log.http.url%s: is required when log.sinks contains http", describeEnv(envKeys.envKey("log.http.url"))))
+	}
+
+	return errors.Join(errs...)
+}
+
+func describeEnv(env string) string {
+	if env == "" {
+		return ""
+	}
+	return " ($" + env + ")"
+}
+
+func describeRule(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		return fmt.Sprintf("is required when %s", strings.Replace(fieldError.Param(), " ", " is ", 1))
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %q", fieldError.Param(), fmt.Sprint(fieldError.Value()))
+	case "min":
+		return fmt.Sprintf("must be at least %s", fieldError.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fieldError.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldError.Param())
+	case "ltefield":
+		return fmt.Sprintf("must not exceed %s", fieldError.Param())
+	default:
+		return fmt.Sprintf("failed %q validation", fieldError.Tag())
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRejectsNonPositiveIntervals(t *testing.T) {
+	for _, tc := range []struct {
+		env, value, want string
+	}{
+		{"FILE_GC_INTERVAL", "-1h", "fileGc.interval ($FILE_GC_INTERVAL): must be greater than 0"},
+		{"FILE_GC_INTERVAL", "0s", "fileGc.interval ($FILE_GC_INTERVAL): must be greater than 0"},
+		{"PARTITION_MAINTENANCE_INTERVAL", "-1h", "partition.interval ($PARTITION_MAINTENANCE_INTERVAL): must be greater than 0"},
+	} {
+		t.Run(tc.env+"="+tc.value, func(t *testing.T) {
+			t.Setenv("JWT_SECRET_KEY", "secret")
+			t.Setenv(tc.env, tc.value)
+
+			_, err := LoadFile("")
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("LoadFile = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package database
+
+import "embed"
+
+// Migrations holds the SQL migrations compiled into the binary, so neither
+// the image nor the working directory needs the migrations folder.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// MigrationsDir is where Migrations lives in the source tree, new migration
+// files are created there.
+const MigrationsDir = "database/migrations"
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS FileReferences;
\ No newline at end of file
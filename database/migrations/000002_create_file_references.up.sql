@@ -0,0 +1,9 @@
+CREATE TABLE FileReferences (
+    file_key TEXT NOT NULL,
+    owner_table VARCHAR(64) NOT NULL,
+    owner_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (file_key, owner_table, owner_id)
+);
+
+CREATE INDEX idx_file_references_owner ON FileReferences (owner_table, owner_id);
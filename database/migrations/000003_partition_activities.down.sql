@@ -0,0 +1,21 @@
+CREATE TABLE activities_unpartitioned (
+    id VARCHAR(255) NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    done_at TIMESTAMP,
+    duration_in_minutes INT CHECK (duration_in_minutes >= 1),
+    calories_burned DECIMAL(10,2),
+    activity_type VARCHAR(10),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES Users (id) ON DELETE CASCADE
+);
+
+INSERT INTO activities_unpartitioned (id, user_id, done_at, duration_in_minutes, calories_burned, activity_type, created_at, updated_at)
+SELECT id, user_id, done_at, duration_in_minutes, calories_burned, activity_type, created_at, updated_at
+FROM Activities;
+
+DROP TABLE Activities;
+DROP FUNCTION create_activities_partition(DATE);
+
+ALTER TABLE activities_unpartitioned RENAME TO Activities;
+ALTER TABLE Activities RENAME CONSTRAINT activities_unpartitioned_pkey TO activities_pkey;
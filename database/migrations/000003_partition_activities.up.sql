@@ -0,0 +1,63 @@
+-- Activities are range partitioned by the month of done_at. Partitions are
+-- named activities_pYYYY_MM and created ahead of time by the application,
+-- rows of a month without a partition land in activities_default.
+ALTER TABLE Activities RENAME TO activities_unpartitioned;
+ALTER TABLE activities_unpartitioned RENAME CONSTRAINT activities_pkey TO activities_unpartitioned_pkey;
+
+CREATE TABLE Activities (
+    id VARCHAR(255) NOT NULL DEFAULT gen_random_uuid(),
+    user_id VARCHAR(255) NOT NULL,
+    done_at TIMESTAMP NOT NULL,
+    duration_in_minutes INT CHECK (duration_in_minutes >= 1),
+    calories_burned DECIMAL(10,2),
+    activity_type VARCHAR(10),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, done_at),
+    FOREIGN KEY (user_id) REFERENCES Users (id) ON DELETE CASCADE
+) PARTITION BY RANGE (done_at);
+
+CREATE TABLE activities_default PARTITION OF Activities DEFAULT;
+
+-- Matches ActivityRepository.GetAll: one user's activities, most recent first
+CREATE INDEX idx_activities_user_done_at ON Activities (user_id, done_at DESC);
+
+-- create_activities_partition adds the partition of the month of month and
+-- moves its rows out of the default partition, which would otherwise block
+-- attaching it. Existing partitions are left alone.
+CREATE FUNCTION create_activities_partition(month DATE) RETURNS TEXT AS $$
+DECLARE
+    start_at DATE := date_trunc('month', month)::DATE;
+    end_at DATE := (start_at + INTERVAL '1 month')::DATE;
+    partition_name TEXT := 'activities_p' || to_char(start_at, 'YYYY_MM');
+BEGIN
+    IF to_regclass(quote_ident(partition_name)) IS NOT NULL THEN
+        RETURN partition_name;
+    END IF;
+
+    EXECUTE format('CREATE TABLE %I (LIKE activities INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', partition_name);
+    EXECUTE format(
+        'WITH moved AS (DELETE FROM activities_default WHERE done_at >= %L AND done_at < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+        start_at, end_at, partition_name
+    );
+    EXECUTE format('ALTER TABLE activities ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', partition_name, start_at, end_at);
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Every month with data up to three months ahead
+SELECT create_activities_partition(month::DATE)
+FROM generate_series(
+    date_trunc('month', LEAST(
+        (SELECT MIN(COALESCE(done_at, created_at)) FROM activities_unpartitioned),
+        CURRENT_TIMESTAMP::TIMESTAMP
+    )),
+    date_trunc('month', CURRENT_TIMESTAMP::TIMESTAMP) + INTERVAL '3 months',
+    INTERVAL '1 month'
+) AS month;
+
+INSERT INTO Activities (id, user_id, done_at, duration_in_minutes, calories_burned, activity_type, created_at, updated_at)
+SELECT id, user_id, COALESCE(done_at, created_at, CURRENT_TIMESTAMP), duration_in_minutes, calories_burned, activity_type, created_at, updated_at
+FROM activities_unpartitioned;
+
+DROP TABLE activities_unpartitioned;
@@ -0,0 +1,80 @@
+ALTER TABLE Activities RENAME TO activities_tz;
+ALTER TABLE activities_tz RENAME CONSTRAINT activities_pkey TO activities_tz_pkey;
+ALTER INDEX idx_activities_user_done_at RENAME TO idx_activities_tz_user_done_at;
+
+DO $$
+DECLARE
+    partition_name TEXT;
+BEGIN
+    FOR partition_name IN
+        SELECT child.relname
+        FROM pg_inherits
+        JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+        WHERE pg_inherits.inhparent = 'activities_tz'::regclass
+    LOOP
+        EXECUTE format('ALTER TABLE %I RENAME TO %I', partition_name, 'activities_tz' || substr(partition_name, length('activities') + 1));
+    END LOOP;
+END $$;
+
+CREATE TABLE Activities (
+    id VARCHAR(255) NOT NULL DEFAULT gen_random_uuid(),
+    user_id VARCHAR(255) NOT NULL,
+    done_at TIMESTAMP NOT NULL,
+    duration_in_minutes INT CHECK (duration_in_minutes >= 1),
+    calories_burned DECIMAL(10,2),
+    activity_type VARCHAR(10),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, done_at),
+    FOREIGN KEY (user_id) REFERENCES Users (id) ON DELETE CASCADE
+) PARTITION BY RANGE (done_at);
+
+CREATE TABLE activities_default PARTITION OF Activities DEFAULT;
+
+CREATE INDEX idx_activities_user_done_at ON Activities (user_id, done_at DESC);
+
+CREATE OR REPLACE FUNCTION create_activities_partition(month DATE) RETURNS TEXT AS $$
+DECLARE
+    start_at DATE := date_trunc('month', month)::DATE;
+    end_at DATE := (start_at + INTERVAL '1 month')::DATE;
+    partition_name TEXT := 'activities_p' || to_char(start_at, 'YYYY_MM');
+BEGIN
+    IF to_regclass(quote_ident(partition_name)) IS NOT NULL THEN
+        RETURN partition_name;
+    END IF;
+
+    EXECUTE format('CREATE TABLE %I (LIKE activities INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', partition_name);
+    EXECUTE format(
+        'WITH moved AS (DELETE FROM activities_default WHERE done_at >= %L AND done_at < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+        start_at, end_at, partition_name
+    );
+    EXECUTE format('ALTER TABLE activities ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', partition_name, start_at, end_at);
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT create_activities_partition(month::DATE)
+FROM generate_series(
+    date_trunc('month', LEAST(
+        (SELECT MIN(done_at) AT TIME ZONE 'UTC' FROM activities_tz),
+        CURRENT_TIMESTAMP AT TIME ZONE 'UTC'
+    )),
+    date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC') + INTERVAL '3 months',
+    INTERVAL '1 month'
+) AS month;
+
+INSERT INTO Activities (id, user_id, done_at, duration_in_minutes, calories_burned, activity_type, created_at, updated_at)
+SELECT
+    id, user_id, done_at AT TIME ZONE 'UTC', duration_in_minutes, calories_burned, activity_type,
+    created_at AT TIME ZONE 'UTC', updated_at AT TIME ZONE 'UTC'
+FROM activities_tz;
+
+DROP TABLE activities_tz;
+
+ALTER TABLE FileReferences
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE Users
+    DROP COLUMN timezone,
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
@@ -0,0 +1,85 @@
+-- Timestamps become TIMESTAMPTZ. Existing values were written by a server
+-- running in UTC and are converted as UTC.
+ALTER TABLE Users
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC',
+    ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE FileReferences
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+-- done_at is the partition key and cannot change its type, so activities
+-- is rebuilt. The old table and its partitions move aside first.
+ALTER TABLE Activities RENAME TO activities_utc;
+ALTER TABLE activities_utc RENAME CONSTRAINT activities_pkey TO activities_utc_pkey;
+ALTER INDEX idx_activities_user_done_at RENAME TO idx_activities_utc_user_done_at;
+
+DO $$
+DECLARE
+    partition_name TEXT;
+BEGIN
+    FOR partition_name IN
+        SELECT child.relname
+        FROM pg_inherits
+        JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+        WHERE pg_inherits.inhparent = 'activities_utc'::regclass
+    LOOP
+        EXECUTE format('ALTER TABLE %I RENAME TO %I', partition_name, 'activities_utc' || substr(partition_name, length('activities') + 1));
+    END LOOP;
+END $$;
+
+CREATE TABLE Activities (
+    id VARCHAR(255) NOT NULL DEFAULT gen_random_uuid(),
+    user_id VARCHAR(255) NOT NULL,
+    done_at TIMESTAMPTZ NOT NULL,
+    duration_in_minutes INT CHECK (duration_in_minutes >= 1),
+    calories_burned DECIMAL(10,2),
+    activity_type VARCHAR(10),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, done_at),
+    FOREIGN KEY (user_id) REFERENCES Users (id) ON DELETE CASCADE
+) PARTITION BY RANGE (done_at);
+
+CREATE TABLE activities_default PARTITION OF Activities DEFAULT;
+
+CREATE INDEX idx_activities_user_done_at ON Activities (user_id, done_at DESC);
+
+-- Partitions hold calendar months in UTC
+CREATE OR REPLACE FUNCTION create_activities_partition(month DATE) RETURNS TEXT AS $$
+DECLARE
+    start_at TIMESTAMPTZ := date_trunc('month', month::TIMESTAMP) AT TIME ZONE 'UTC';
+    end_at TIMESTAMPTZ := (date_trunc('month', month::TIMESTAMP) + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    partition_name TEXT := 'activities_p' || to_char(month::TIMESTAMP, 'YYYY_MM');
+BEGIN
+    IF to_regclass(quote_ident(partition_name)) IS NOT NULL THEN
+        RETURN partition_name;
+    END IF;
+
+    EXECUTE format('CREATE TABLE %I (LIKE activities INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', partition_name);
+    EXECUTE format(
+        'WITH moved AS (DELETE FROM activities_default WHERE done_at >= %L AND done_at < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+        start_at, end_at, partition_name
+    );
+    EXECUTE format('ALTER TABLE activities ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', partition_name, start_at, end_at);
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT create_activities_partition(month::DATE)
+FROM generate_series(
+    date_trunc('month', LEAST(
+        (SELECT MIN(done_at) FROM activities_utc),
+        CURRENT_TIMESTAMP AT TIME ZONE 'UTC'
+    )),
+    date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC') + INTERVAL '3 months',
+    INTERVAL '1 month'
+) AS month;
+
+INSERT INTO Activities (id, user_id, done_at, duration_in_minutes, calories_burned, activity_type, created_at, updated_at)
+SELECT
+    id, user_id, done_at AT TIME ZONE 'UTC', duration_in_minutes, calories_burned, activity_type,
+    created_at AT TIME ZONE 'UTC', updated_at AT TIME ZONE 'UTC'
+FROM activities_utc;
+
+DROP TABLE activities_utc;
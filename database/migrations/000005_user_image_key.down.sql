@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_image_key;
+ALTER TABLE Users DROP COLUMN IF EXISTS image_key;
@@ -0,0 +1,9 @@
+-- image_key is the storage key of image_uri, the path of the URL. The file
+-- GC matches it whole instead of comparing suffixes of the URI.
+ALTER TABLE Users ADD COLUMN image_key TEXT;
+
+UPDATE Users
+SET image_key = NULLIF(substring(image_uri FROM '^(?:[A-Za-z][A-Za-z0-9+.-]*:)?(?://[^/?#]*)?/?([^?#]*)'), '')
+WHERE image_uri IS NOT NULL;
+
+CREATE INDEX idx_users_image_key ON Users (image_key) WHERE image_key IS NOT NULL;
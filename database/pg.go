@@ -7,60 +7,94 @@ import (
 	"strconv"
 
 	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/tracing"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/samber/do/v2"
 )
 
-func NewUserRepositoryInject(i do.Injector) (*pgxpool.Pool, error) {
-	config := config.LoadConfig()
-	databaseURL := config.DatabaseURL
+var execModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
 
-	tempPool, err := pgxpool.New(context.Background(), databaseURL)
-	if err != nil {
-		log.Fatalf("Unable to connect to database: %v", err)
-	}
-	defer tempPool.Close()
+// DB owns the application pool so the injector can close it on shutdown.
+type DB struct {
+	*pgxpool.Pool
+}
 
-	var maxConnectionText string
-	row := tempPool.QueryRow(context.Background(), "SHOW max_connections")
-	err = row.Scan(&maxConnectionText)
-	if err != nil {
-		panic(err)
-	}
+// Shutdown waits for acquired connections to be released and closes the pool.
+func (db *DB) Shutdown() {
+	db.Close()
+	log.Println("Database pool closed")
+}
 
-	maxConnection, err := strconv.Atoi(maxConnectionText)
+// HealthCheck acquires a connection and pings the server.
+func (db *DB) HealthCheck(ctx context.Context) error {
+	return db.Ping(ctx)
+}
+
+// NewPoolConfig turns cfg into the pgx pool settings, every query is traced.
+func NewPoolConfig(cfg config.DatabaseConfig) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL())
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("parse database URL: %w", err)
 	}
 
-	maxOpenConnects := int(float64(maxConnection) * 0.8)
+	pool := cfg.Pool
+	execMode, ok := execModes[pool.ExecMode]
+	if !ok {
+		return nil, fmt.Errorf("unknown exec mode %q", pool.ExecMode)
+	}
 
-	fmt.Println(maxOpenConnects)
-	fmt.Println(maxConnection)
+	poolConfig.MaxConns = int32(pool.MaxConns)
+	poolConfig.MinConns = int32(pool.MinConns)
+	poolConfig.MaxConnLifetime = pool.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = pool.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = pool.HealthCheckPeriod
 
-	poolConfig, err := pgxpool.ParseConfig(databaseURL)
-	if err != nil {
-		log.Fatalf("Unable to parse database URL: %v", err)
+	connConfig := poolConfig.ConnConfig
+	connConfig.ConnectTimeout = pool.ConnectTimeout
+	connConfig.DefaultQueryExecMode = execMode
+	connConfig.StatementCacheCapacity = pool.StatementCacheCapacity
+	connConfig.Tracer = tracing.QueryTracer{}
+	if pool.ApplicationName != "" {
+		connConfig.RuntimeParams["application_name"] = pool.ApplicationName
 	}
+	if pool.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(pool.StatementTimeout.Milliseconds(), 10)
+	}
+	return poolConfig, nil
+}
 
-	poolConfig.MaxConns = int32(maxOpenConnects)
-
-	db, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+// NewDB opens the pool and checks that the server is reachable.
+func NewDB(ctx context.Context, cfg config.DatabaseConfig) (*DB, error) {
+	poolConfig, err := NewPoolConfig(cfg)
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v", err)
 		return nil, err
 	}
-	log.Println("Connected to the database successfully")
 
-	return db, nil
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	log.Printf("Connected to the database, pool of up to %d connections", poolConfig.MaxConns)
+	return &DB{Pool: pool}, nil
+}
 
+func NewDBInject(i do.Injector) (*DB, error) {
+	cfg := do.MustInvoke[*config.Config](i)
+	return NewDB(context.Background(), cfg.Database)
 }
 
-func Connect(databaseURL string) *pgxpool.Pool {
-	db, err := pgxpool.New(context.Background(), databaseURL)
-	if err != nil {
-		log.Fatalf("Unable to connect to database: %v", err)
-	}
-	log.Println("Connected to the database successfully")
-	return db
+// NewPoolInject hands out the pool of DB to the repositories.
+func NewPoolInject(i do.Injector) (*pgxpool.Pool, error) {
+	return do.MustInvoke[*DB](i).Pool, nil
 }
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/jackc/pgx/v5"
+)
+
+func testDatabaseConfig() config.DatabaseConfig {
+	return config.DatabaseConfig{
+		User: "fitbyte",
+		Host: "db.internal",
+		Port: 5432,
+		Name: "fitbyte",
+		Pool: config.PoolConfig{
+			MaxConns:               20,
+			MinConns:               2,
+			MaxConnLifetime:        time.Hour,
+			MaxConnIdleTime:        10 * time.Minute,
+			HealthCheckPeriod:      time.Minute,
+			ConnectTimeout:         3 * time.Second,
+			StatementTimeout:       1500 * time.Millisecond,
+			ApplicationName:        "fitbyte-api",
+			ExecMode:               "cache_describe",
+			StatementCacheCapacity: 128,
+		},
+	}
+}
+
+func TestNewPoolConfig(t *testing.T) {
+	poolConfig, err := NewPoolConfig(testDatabaseConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if poolConfig.MaxConns != 20 || poolConfig.MinConns != 2 {
+		t.Errorf("conns = %d..%d, want 2..20", poolConfig.MinConns, poolConfig.MaxConns)
+	}
+	if poolConfig.MaxConnLifetime != time.Hour || poolConfig.MaxConnIdleTime != 10*time.Minute || poolConfig.HealthCheckPeriod != time.Minute {
+		t.Errorf("lifetimes = %v/%v/%v", poolConfig.MaxConnLifetime, poolConfig.MaxConnIdleTime, poolConfig.HealthCheckPeriod)
+	}
+
+	connConfig := poolConfig.ConnConfig
+	if connConfig.ConnectTimeout != 3*time.Second {
+		t.Errorf("ConnectTimeout = %v, want 3s", connConfig.ConnectTimeout)
+	}
+	if connConfig.DefaultQueryExecMode != pgx.QueryExecModeCacheDescribe || connConfig.StatementCacheCapacity != 128 {
+		t.Errorf("exec mode = %v with %d statements cached", connConfig.DefaultQueryExecMode, connConfig.StatementCacheCapacity)
+	}
+	if got := connConfig.RuntimeParams["statement_timeout"]; got != "1500" {
+		t.Errorf("statement_timeout = %q, want 1500", got)
+	}
+	if got := connConfig.RuntimeParams["application_name"]; got != "fitbyte-api" {
+		t.Errorf("application_name = %q, want fitbyte-api", got)
+	}
+	if connConfig.Tracer == nil {
+		t.Error("queries are not traced")
+	}
+}
+
+func TestNewPoolConfigWithoutStatementTimeout(t *testing.T) {
+	cfg := testDatabaseConfig()
+	cfg.Pool.StatementTimeout = 0
+	poolConfig, err := NewPoolConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := poolConfig.ConnConfig.RuntimeParams["statement_timeout"]; ok {
+		t.Errorf("statement_timeout = %q, want the server default", got)
+	}
+}
+
+func TestNewPoolConfigRejectsUnknownExecMode(t *testing.T) {
+	cfg := testDatabaseConfig()
+	cfg.Pool.ExecMode = "prepared"
+	if _, err := NewPoolConfig(cfg); err == nil {
+		t.Fatal("expected an error for an unknown exec mode")
+	}
+}
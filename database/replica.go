@@ -0,0 +1,390 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/metrics"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/do/v2"
+)
+
+const (
+	targetPrimary = "primary"
+	targetReplica = "replica"
+)
+
+// replicaLagQuery reports 0 while the replica has replayed everything it
+// received, pg_last_xact_replay_timestamp alone grows on an idle primary.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END::FLOAT8
+`
+
+// ReadDBTX is what repositories run read-only queries through. They may be
+// served by a replica, so anything that must see a preceding write of the
+// same request belongs on DBTX.
+type ReadDBTX interface {
+	DBTX
+}
+
+type sessionKey struct{}
+
+// WithSession names the caller of ctx, e.g. the authenticated user, for
+// read-your-writes: after a write in the session its reads go to the
+// primary for ReplicaConfig.ReadYourWrites.
+func WithSession(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, key)
+}
+
+func sessionFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(sessionKey{}).(string)
+	return key
+}
+
+// Replica is a read replica and the connection to query it through.
+type Replica struct {
+	Name string
+	DB   DBTX
+
+	// lag in nanoseconds, negative while unreachable
+	lag atomic.Int64
+}
+
+// ReplicaSet sends reads to the replicas that are not lagging behind and
+// everything else to the primary.
+type ReplicaSet struct {
+	primary        DBTX
+	replicas       []*Replica
+	maxLag         time.Duration
+	readYourWrites time.Duration
+	now            func() time.Time
+
+	next atomic.Uint64
+
+	mu     sync.Mutex
+	writes map[string]time.Time
+	// shared holds the writes for the other instances, nil when the cache
+	// is per instance
+	shared cache.Store
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewReplicaSet routes between the primary pool and replicas, which stay
+// excluded until CheckLag found them healthy. A shared store makes a write
+// on one instance send the session's reads on every other to the primary
+// too, store may be nil.
+func NewReplicaSet(primary DBTX, cfg config.ReplicaConfig, store cache.Store, replicas ...*Replica) *ReplicaSet {
+	for _, replica := range replicas {
+		replica.lag.Store(-1)
+	}
+	set := &ReplicaSet{
+		primary:        NewContextDB(primary),
+		replicas:       replicas,
+		maxLag:         cfg.MaxLag,
+		readYourWrites: cfg.ReadYourWrites,
+		now:            time.Now,
+		writes:         make(map[string]time.Time),
+	}
+	if store != nil && cache.Shared(store) {
+		set.shared = store
+	}
+	return set
+}
+
+func NewReplicaSetInject(i do.Injector) (*ReplicaSet, error) {
+	cfg := do.MustInvoke[*config.Config](i)
+	db := do.MustInvoke[*DB](i)
+
+	replicas := make([]*Replica, 0, len(cfg.Database.Replicas.Hosts))
+	for _, host := range cfg.Database.Replicas.Hosts {
+		replicaCfg, err := cfg.Database.Replica(host)
+		if err != nil {
+			closeReplicas(replicas)
+			return nil, err
+		}
+		poolConfig, err := NewPoolConfig(replicaCfg)
+		if err != nil {
+			closeReplicas(replicas)
+			return nil, err
+		}
+		// A replica that is down at start is retried by every lag check
+		pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+		if err != nil {
+			closeReplicas(replicas)
+			return nil, fmt.Errorf("replica %s: %w", host, err)
+		}
+		replicas = append(replicas, &Replica{Name: host, DB: pool})
+	}
+
+	store := do.MustInvoke[cache.Store](i)
+	set := NewReplicaSet(db.Pool, cfg.Database.Replicas, store, replicas...)
+	if len(replicas) > 0 {
+		set.CheckLag(context.Background())
+		set.Start(cfg.Database.Replicas.LagCheckPeriod)
+		log.Printf("Routing reads to %d replicas", len(replicas))
+	}
+	return set, nil
+}
+
+// Writer runs every query on the primary, or in the transaction of the
+// context. Exec, CopyFrom, SendBatch and any query in a transaction not
+// opened read-only count as a write of the session for read-your-writes,
+// a write through Query or QueryRow outside of one needs MarkWritten.
+func (s *ReplicaSet) Writer() DBTX {
+	return primaryDB{set: s}
+}
+
+// Reader runs queries on a healthy replica, and on the primary inside a
+// transaction, right after a write of the session or when no replica is
+// healthy.
+func (s *ReplicaSet) Reader() ReadDBTX {
+	return replicaDB{set: s}
+}
+
+func NewDBTXInject(i do.Injector) (DBTX, error) {
+	return do.MustInvoke[*ReplicaSet](i).Writer(), nil
+}
+
+func NewReadDBTXInject(i do.Injector) (ReadDBTX, error) {
+	return do.MustInvoke[*ReplicaSet](i).Reader(), nil
+}
+
+// MarkWritten starts read-your-writes for session key, for writes made
+// before the session is in the context, e.g. registering a user. db is
+// the DBTX the write went through, other implementations are ignored.
+func MarkWritten(ctx context.Context, db DBTX, key string) {
+	if primary, ok := db.(primaryDB); ok {
+		primary.set.markWrite(ctx, key)
+	}
+}
+
+func (s *ReplicaSet) markWrite(ctx context.Context, key string) {
+	if key == "" || s.readYourWrites <= 0 || len(s.replicas) == 0 {
+		return
+	}
+	at := s.now()
+	s.mu.Lock()
+	s.writes[key] = at
+	s.mu.Unlock()
+
+	if s.shared != nil {
+		// The write itself must not fail for it, other instances may then
+		// read from a replica that lags behind
+		err := s.shared.Set(context.WithoutCancel(ctx), fmt.Sprintf(cache.CacheSessionWrote, key), strconv.FormatInt(at.UnixNano(), 10), s.readYourWrites)
+		if err != nil {
+			log.Printf("Failed to share the write of session %s: %v", key, err)
+		}
+	}
+}
+
+func (s *ReplicaSet) wroteRecently(ctx context.Context, key string) bool {
+	if key == "" {
+		return false
+	}
+	s.mu.Lock()
+	at, ok := s.writes[key]
+	s.mu.Unlock()
+	if ok && s.now().Sub(at) < s.readYourWrites {
+		return true
+	}
+	if s.shared == nil {
+		return false
+	}
+
+	value, found, err := s.shared.Get(ctx, fmt.Sprintf(cache.CacheSessionWrote, key))
+	if err != nil {
+		// Unknown, the primary is never behind
+		return true
+	}
+	if !found {
+		return false
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	return err != nil || s.now().Sub(time.Unix(0, nanos)) < s.readYourWrites
+}
+
+// reader picks where a read-only query of ctx runs.
+func (s *ReplicaSet) reader(ctx context.Context) DBTX {
+	if _, ok := TxFromContext(ctx); ok {
+		return s.primary
+	}
+	if len(s.replicas) > 0 && !s.wroteRecently(ctx, sessionFromContext(ctx)) {
+		if replica := s.healthyReplica(); replica != nil {
+			metrics.DBReads.WithLabelValues(targetReplica).Inc()
+			return replica.DB
+		}
+	}
+	metrics.DBReads.WithLabelValues(targetPrimary).Inc()
+	return s.primary
+}
+
+// healthyReplica takes turns between the replicas within MaxLag.
+func (s *ReplicaSet) healthyReplica() *Replica {
+	n := uint64(len(s.replicas))
+	if n == 0 {
+		return nil
+	}
+	start := s.next.Add(1) - 1
+	for i := uint64(0); i < n; i++ {
+		replica := s.replicas[(start+i)%n]
+		if s.healthy(time.Duration(replica.lag.Load())) {
+			return replica
+		}
+	}
+	return nil
+}
+
+func (s *ReplicaSet) healthy(lag time.Duration) bool {
+	return lag >= 0 && lag <= s.maxLag
+}
+
+// CheckLag measures the lag of every replica and forgets writes older than
+// ReadYourWrites.
+func (s *ReplicaSet) CheckLag(ctx context.Context) {
+	for _, replica := range s.replicas {
+		var seconds float64
+		lag := time.Duration(-1)
+		err := replica.DB.QueryRow(ctx, replicaLagQuery).Scan(&seconds)
+		if err == nil {
+			lag = time.Duration(seconds * float64(time.Second))
+		}
+
+		wasHealthy := s.healthy(time.Duration(replica.lag.Swap(int64(lag))))
+		switch {
+		case wasHealthy && err != nil:
+			log.Printf("Replica %s excluded from reads: %v", replica.Name, err)
+		case wasHealthy && !s.healthy(lag):
+			log.Printf("Replica %s excluded from reads, %v behind the primary", replica.Name, lag)
+		case !wasHealthy && s.healthy(lag):
+			log.Printf("Replica %s serving reads, %v behind the primary", replica.Name, lag)
+		}
+		if err != nil {
+			metrics.ReplicaLag.WithLabelValues(replica.Name).Set(-1)
+		} else {
+			metrics.ReplicaLag.WithLabelValues(replica.Name).Set(lag.Seconds())
+		}
+	}
+
+	s.mu.Lock()
+	for key, at := range s.writes {
+		if s.now().Sub(at) >= s.readYourWrites {
+			delete(s.writes, key)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// Start checks the lag every period until Shutdown.
+func (s *ReplicaSet) Start(period time.Duration) {
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go func(stop <-chan struct{}, stopped chan<- struct{}) {
+		defer close(stopped)
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), period)
+				s.CheckLag(ctx)
+				cancel()
+			}
+		}
+	}(s.stop, s.stopped)
+}
+
+// Shutdown stops the lag checks and closes the replica pools.
+func (s *ReplicaSet) Shutdown() {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.stopped
+		s.stop = nil
+	}
+	closeReplicas(s.replicas)
+}
+
+func closeReplicas(replicas []*Replica) {
+	for _, replica := range replicas {
+		if pool, ok := replica.DB.(interface{ Close() }); ok {
+			pool.Close()
+		}
+	}
+}
+
+type primaryDB struct {
+	set *ReplicaSet
+}
+
+// write is where a statement that writes runs.
+func (p primaryDB) write(ctx context.Context) DBTX {
+	p.set.markWrite(ctx, sessionFromContext(ctx))
+	return p.set.primary
+}
+
+// query is where a query runs, it may write only in a transaction.
+func (p primaryDB) query(ctx context.Context) DBTX {
+	if inWriteTx(ctx) {
+		p.set.markWrite(ctx, sessionFromContext(ctx))
+	}
+	return p.set.primary
+}
+
+func (p primaryDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return p.write(ctx).Exec(ctx, sql, args...)
+}
+
+func (p primaryDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return p.query(ctx).Query(ctx, sql, args...)
+}
+
+func (p primaryDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return p.query(ctx).QueryRow(ctx, sql, args...)
+}
+
+func (p primaryDB) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error) {
+	return p.write(ctx).CopyFrom(ctx, table, columns, rows)
+}
+
+func (p primaryDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return p.write(ctx).SendBatch(ctx, b)
+}
+
+type replicaDB struct {
+	set *ReplicaSet
+}
+
+func (r replicaDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return r.set.reader(ctx).Exec(ctx, sql, args...)
+}
+
+func (r replicaDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return r.set.reader(ctx).Query(ctx, sql, args...)
+}
+
+func (r replicaDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return r.set.reader(ctx).QueryRow(ctx, sql, args...)
+}
+
+func (r replicaDB) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error) {
+	return r.set.reader(ctx).CopyFrom(ctx, table, columns, rows)
+}
+
+func (r replicaDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return r.set.reader(ctx).SendBatch(ctx, b)
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeConn answers the lag query with lag and records where every other
+// query ran.
+type fakeConn struct {
+	name string
+	lag  float64
+	err  error
+	log  *[]string
+}
+
+type fakeRow struct {
+	scan func(dest ...any) error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	return r.scan(dest...)
+}
+
+func (c *fakeConn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	*c.log = append(*c.log, c.name)
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *fakeConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	*c.log = append(*c.log, c.name)
+	return nil, nil
+}
+
+func (c *fakeConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if sql == replicaLagQuery {
+		return fakeRow{scan: func(dest ...any) error {
+			if c.err != nil {
+				return c.err
+			}
+			*dest[0].(*float64) = c.lag
+			return nil
+		}}
+	}
+	*c.log = append(*c.log, c.name)
+	return fakeRow{scan: func(dest ...any) error { return nil }}
+}
+
+func (c *fakeConn) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error) {
+	*c.log = append(*c.log, c.name)
+	return 0, nil
+}
+
+func (c *fakeConn) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	*c.log = append(*c.log, c.name)
+	return nil
+}
+
+type replicaFixture struct {
+	set     *ReplicaSet
+	log     []string
+	a, b    *fakeConn
+	primary *fakeConn
+	now     time.Time
+}
+
+func newReplicaFixture() *replicaFixture {
+	return newSharedReplicaFixture(nil)
+}
+
+// newSharedReplicaFixture shares read-your-writes through store.
+func newSharedReplicaFixture(store cache.Store) *replicaFixture {
+	f := &replicaFixture{now: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)}
+	f.primary = &fakeConn{name: "primary", log: &f.log}
+	f.a = &fakeConn{name: "a", log: &f.log}
+	f.b = &fakeConn{name: "b", log: &f.log}
+	f.set = NewReplicaSet(f.primary, config.ReplicaConfig{MaxLag: 5 * time.Second, ReadYourWrites: 3 * time.Second}, store,
+		&Replica{Name: "a", DB: f.a},
+		&Replica{Name: "b", DB: f.b},
+	)
+	f.set.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *replicaFixture) read(ctx context.Context, n int) []string {
+	f.log = nil
+	for i := 0; i < n; i++ {
+		f.set.Reader().QueryRow(ctx, "SELECT 1")
+	}
+	return f.log
+}
+
+func TestReaderBalancesHealthyReplicas(t *testing.T) {
+	f := newReplicaFixture()
+	ctx := context.Background()
+
+	equalLog(t, f.read(ctx, 1), "primary") // before the first lag check
+
+	f.set.CheckLag(ctx)
+	equalLog(t, f.read(ctx, 4), "b", "a", "b", "a")
+
+	f.b.lag = 30
+	f.set.CheckLag(ctx)
+	equalLog(t, f.read(ctx, 2), "a", "a")
+
+	f.a.err = errors.New("connection refused")
+	f.set.CheckLag(ctx)
+	equalLog(t, f.read(ctx, 1), "primary")
+
+	f.a.err, f.b.lag = nil, 1
+	f.set.CheckLag(ctx)
+	equalLog(t, f.read(ctx, 2), "a", "b")
+}
+
+func TestReaderSticksToPrimaryAfterWrite(t *testing.T) {
+	f := newReplicaFixture()
+	f.set.CheckLag(context.Background())
+	jane := WithSession(context.Background(), "jane")
+	john := WithSession(context.Background(), "john")
+
+	f.set.Writer().Exec(jane, "UPDATE users SET name = 'Jane'")
+	equalLog(t, f.log, "primary")
+	equalLog(t, f.read(jane, 1), "primary")
+	equalLog(t, f.read(john, 1), "a")
+
+	f.now = f.now.Add(3 * time.Second)
+	equalLog(t, f.read(jane, 1), "b")
+
+	MarkWritten(context.Background(), f.set.Writer(), "john")
+	equalLog(t, f.read(john, 1), "primary")
+	f.now = f.now.Add(3 * time.Second)
+	f.set.CheckLag(context.Background())
+	if len(f.set.writes) != 0 {
+		t.Errorf("expired writes kept: %v", f.set.writes)
+	}
+}
+
+func TestWriterQueriesMarkWritesOnlyInWriteTransactions(t *testing.T) {
+	f := newReplicaFixture()
+	f.set.CheckLag(context.Background())
+	jane := WithSession(context.Background(), "jane")
+
+	f.set.Writer().QueryRow(jane, "SELECT 1")
+	equalLog(t, f.read(jane, 1), "a")
+
+	tx := pgx.Tx(&fakeTxConn{fakeConn{name: "tx", log: &f.log}})
+	readOnly := context.WithValue(context.WithValue(jane, txKey{}, tx), readOnlyKey{}, true)
+	f.set.Writer().Query(readOnly, "SELECT 1")
+	equalLog(t, f.read(jane, 1), "b")
+
+	f.set.Writer().QueryRow(context.WithValue(jane, txKey{}, tx), "UPDATE users SET name = 'Jane' RETURNING id")
+	equalLog(t, f.read(jane, 1), "primary")
+}
+
+func TestReaderSticksToPrimaryAfterWriteOnAnotherInstance(t *testing.T) {
+	server := miniredis.RunT(t)
+	store, err := cache.NewRedisStore(server.Addr(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	writer, reader := newSharedReplicaFixture(store), newSharedReplicaFixture(store)
+	reader.set.CheckLag(context.Background())
+	jane := WithSession(context.Background(), "jane")
+
+	writer.set.Writer().Exec(jane, "UPDATE users SET name = 'Jane'")
+	equalLog(t, reader.read(jane, 1), "primary")
+
+	reader.now = reader.now.Add(3 * time.Second)
+	equalLog(t, reader.read(jane, 1), "a")
+}
+
+func TestReaderUsesTransactionOfContext(t *testing.T) {
+	f := newReplicaFixture()
+	f.set.CheckLag(context.Background())
+	ctx := context.WithValue(context.Background(), txKey{}, pgx.Tx(&fakeTxConn{fakeConn{name: "tx", log: &f.log}}))
+
+	equalLog(t, f.read(ctx, 1), "tx")
+}
+
+// fakeTxConn is a transaction that only answers queries.
+type fakeTxConn struct {
+	fakeConn
+}
+
+func (t *fakeTxConn) Begin(ctx context.Context) (pgx.Tx, error) { return nil, nil }
+func (t *fakeTxConn) Commit(ctx context.Context) error          { return nil }
+func (t *fakeTxConn) Rollback(ctx context.Context) error        { return nil }
+func (t *fakeTxConn) LargeObjects() pgx.LargeObjects            { return pgx.LargeObjects{} }
+func (t *fakeTxConn) Conn() *pgx.Conn                           { return nil }
+func (t *fakeTxConn) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	return nil, nil
+}
+
+func TestReplicaConfigHost(t *testing.T) {
+	primary := config.DatabaseConfig{User: "fitbyte", Host: "primary", Port: 5432, Name: "fitbyte"}
+	for host, want := range map[string]string{
+		"replica-1":      "postgres://fitbyte:@replica-1:5432/fitbyte",
+		"replica-2:6432": "postgres://fitbyte:@replica-2:6432/fitbyte",
+	} {
+		replica, err := primary.Replica(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := replica.URL(); got != want {
+			t.Errorf("Replica(%q).URL() = %q, want %q", host, got, want)
+		}
+	}
+	if _, err := primary.Replica("replica:port"); err == nil {
+		t.Error("expected an error for an invalid port")
+	}
+}
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/tracing"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/samber/do/v2"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DBTX is what repositories query through, satisfied by the pool and by
+// pgx.Tx alike.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type txKey struct{}
+
+type readOnlyKey struct{}
+
+// TxFromContext returns the transaction WithinTx stored in ctx.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// inWriteTx reports a transaction in ctx that was not opened read-only.
+func inWriteTx(ctx context.Context) bool {
+	if _, ok := TxFromContext(ctx); !ok {
+		return false
+	}
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return !readOnly
+}
+
+// contextDB runs every call in the transaction of the context, if any, and
+// on the pool otherwise, so repositories join a unit of work unchanged.
+type contextDB struct {
+	pool DBTX
+}
+
+func NewContextDB(pool DBTX) DBTX {
+	return contextDB{pool: pool}
+}
+
+func (c contextDB) conn(ctx context.Context) DBTX {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return c.pool
+}
+
+func (c contextDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return c.conn(ctx).Exec(ctx, sql, args...)
+}
+
+func (c contextDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return c.conn(ctx).Query(ctx, sql, args...)
+}
+
+func (c contextDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return c.conn(ctx).QueryRow(ctx, sql, args...)
+}
+
+func (c contextDB) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error) {
+	return c.conn(ctx).CopyFrom(ctx, table, columns, rows)
+}
+
+func (c contextDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return c.conn(ctx).SendBatch(ctx, b)
+}
+
+// Beginner starts transactions, e.g. *pgxpool.Pool.
+type Beginner interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+}
+
+const retryBackoff = 10 * time.Millisecond
+
+type txConfig struct {
+	options    pgx.TxOptions
+	maxRetries int
+}
+
+type TxOption func(*txConfig)
+
+// WithIsolation overrides the configured isolation level.
+func WithIsolation(level pgx.TxIsoLevel) TxOption {
+	return func(c *txConfig) { c.options.IsoLevel = level }
+}
+
+// WithReadOnly starts a read-only transaction.
+func WithReadOnly() TxOption {
+	return func(c *txConfig) { c.options.AccessMode = pgx.ReadOnly }
+}
+
+// WithRetries overrides how often a serialization failure or deadlock is
+// retried, 0 disables retries.
+func WithRetries(n int) TxOption {
+	return func(c *txConfig) { c.maxRetries = n }
+}
+
+// Transactor runs fn as one unit of work, see TxManager.WithinTx.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error
+}
+
+// TxManager runs units of work spanning several repositories.
+type TxManager struct {
+	db       Beginner
+	defaults txConfig
+}
+
+func NewTxManager(db Beginner, cfg config.DatabaseConfig) *TxManager {
+	return &TxManager{
+		db: db,
+		defaults: txConfig{
+			options:    pgx.TxOptions{IsoLevel: pgx.TxIsoLevel(cfg.TxIsolation)},
+			maxRetries: cfg.TxMaxRetries,
+		},
+	}
+}
+
+func NewTxManagerInject(i do.Injector) (*TxManager, error) {
+	cfg := do.MustInvoke[*config.Config](i)
+	return NewTxManager(do.MustInvoke[*DB](i).Pool, cfg.Database), nil
+}
+
+// WithinTx calls fn with a context carrying a transaction, which is
+// committed when fn returns nil and rolled back otherwise, also when fn
+// panics. Inside another WithinTx it uses a savepoint of the outer
+// transaction and opts are ignored. Serialization failures and deadlocks
+// run fn again in a new transaction, so fn must not have side effects
+// outside the database.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error {
+	if outer, ok := TxFromContext(ctx); ok {
+		return runTx(ctx, outer.Begin, fn)
+	}
+
+	cfg := m.defaults
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.options.AccessMode == pgx.ReadOnly {
+		ctx = context.WithValue(ctx, readOnlyKey{}, true)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := m.attempt(ctx, cfg, attempt, fn)
+		if err == nil || !retryable(err) || attempt >= cfg.maxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(time.Duration(attempt+1) * retryBackoff):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+func (m *TxManager) attempt(ctx context.Context, cfg txConfig, attempt int, fn func(ctx context.Context) error) (err error) {
+	ctx, span := tracing.Start(ctx, "db transaction",
+		attribute.String("db.transaction.isolation", string(cfg.options.IsoLevel)),
+		attribute.Int("db.transaction.attempt", attempt),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return runTx(ctx, func(ctx context.Context) (pgx.Tx, error) {
+		return m.db.BeginTx(ctx, cfg.options)
+	}, fn)
+}
+
+func runTx(ctx context.Context, begin func(ctx context.Context) (pgx.Tx, error), fn func(ctx context.Context) error) (err error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// retryable reports a serialization failure or a deadlock, both succeed
+// when the transaction is simply run again.
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == pgerrcode.SerializationFailure || pgErr.Code == pgerrcode.DeadlockDetected
+}
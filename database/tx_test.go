@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeTx records how it ended, nested transactions are savepoints of it.
+type fakeTx struct {
+	pgx.Tx
+	name      string
+	log       *[]string
+	commitErr error
+}
+
+func (t *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	*t.log = append(*t.log, "savepoint "+t.name)
+	return &fakeTx{name: t.name + "/savepoint", log: t.log}, nil
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	*t.log = append(*t.log, "commit "+t.name)
+	return t.commitErr
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	*t.log = append(*t.log, "rollback "+t.name)
+	return nil
+}
+
+type fakeBeginner struct {
+	log       []string
+	options   []pgx.TxOptions
+	commitErr []error // per attempt
+}
+
+func (b *fakeBeginner) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	b.options = append(b.options, opts)
+	tx := &fakeTx{name: "tx", log: &b.log}
+	if len(b.commitErr) > 0 {
+		tx.commitErr, b.commitErr = b.commitErr[0], b.commitErr[1:]
+	}
+	return tx, nil
+}
+
+func newTestTxManager(db *fakeBeginner) *TxManager {
+	return NewTxManager(db, config.DatabaseConfig{TxIsolation: "read committed", TxMaxRetries: 2})
+}
+
+func equalLog(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("log = %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("log = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestWithinTxNestsSavepoints(t *testing.T) {
+	db := &fakeBeginner{}
+	m := newTestTxManager(db)
+
+	errInner := errors.New("inner failed")
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		outer, _ := TxFromContext(ctx)
+		err := m.WithinTx(ctx, func(ctx context.Context) error {
+			if inner, _ := TxFromContext(ctx); inner == outer {
+				t.Error("nested call did not get a savepoint")
+			}
+			return errInner
+		})
+		if !errors.Is(err, errInner) {
+			t.Errorf("nested err = %v", err)
+		}
+		return nil
+	}, WithIsolation(pgx.Serializable))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equalLog(t, db.log, "savepoint tx", "rollback tx/savepoint", "commit tx")
+	if db.options[0].IsoLevel != pgx.Serializable {
+		t.Errorf("isolation = %q", db.options[0].IsoLevel)
+	}
+}
+
+func TestWithinTxTellsReadOnlyTransactions(t *testing.T) {
+	m := newTestTxManager(&fakeBeginner{})
+
+	m.WithinTx(context.Background(), func(ctx context.Context) error {
+		if !inWriteTx(ctx) {
+			t.Error("a read-write transaction does not write")
+		}
+		return nil
+	})
+	m.WithinTx(context.Background(), func(ctx context.Context) error {
+		return m.WithinTx(ctx, func(ctx context.Context) error {
+			if inWriteTx(ctx) {
+				t.Error("a savepoint of a read-only transaction writes")
+			}
+			return nil
+		})
+	}, WithReadOnly())
+	if inWriteTx(context.Background()) {
+		t.Error("no transaction writes")
+	}
+}
+
+func TestWithinTxRetriesSerializationFailures(t *testing.T) {
+	conflict := &pgconn.PgError{Code: "40001"}
+	db := &fakeBeginner{commitErr: []error{conflict, conflict}}
+	m := newTestTxManager(db)
+
+	calls := 0
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("err = %v after %d calls, want success on the third", err, calls)
+	}
+	if db.options[0].IsoLevel != pgx.ReadCommitted {
+		t.Errorf("default isolation = %q", db.options[0].IsoLevel)
+	}
+
+	db = &fakeBeginner{commitErr: []error{conflict, conflict, conflict}}
+	err = newTestTxManager(db).WithinTx(context.Background(), func(ctx context.Context) error { return nil })
+	if !errors.Is(err, conflict) {
+		t.Fatalf("err = %v, want the conflict once retries are used up", err)
+	}
+
+	db = &fakeBeginner{}
+	calls = 0
+	errPlain := errors.New("not retryable")
+	err = newTestTxManager(db).WithinTx(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errPlain
+	})
+	if !errors.Is(err, errPlain) || calls != 1 {
+		t.Fatalf("err = %v after %d calls", err, calls)
+	}
+	equalLog(t, db.log, "rollback tx")
+}
+
+func TestWithinTxRollsBackOnPanic(t *testing.T) {
+	db := &fakeBeginner{}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("panic was swallowed")
+		}
+		equalLog(t, db.log, "rollback tx")
+	}()
+
+	_ = newTestTxManager(db).WithinTx(context.Background(), func(ctx context.Context) error {
+		panic("boom")
+	})
+}
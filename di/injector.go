@@ -1,16 +1,18 @@
 package di
 
 import (
-	"fmt"
-	"os"
-
+	"github.com/TimDebug/FitByte/auth"
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/config"
 	"github.com/TimDebug/FitByte/database"
 	"github.com/TimDebug/FitByte/domain"
 	"github.com/TimDebug/FitByte/handler"
+	"github.com/TimDebug/FitByte/infrastructure/migration"
 	"github.com/TimDebug/FitByte/infrastructure/storage"
 	"github.com/TimDebug/FitByte/logger"
 	"github.com/TimDebug/FitByte/repository"
 	"github.com/TimDebug/FitByte/service"
+	"github.com/TimDebug/FitByte/tracing"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/samber/do/v2"
@@ -19,34 +21,55 @@ import (
 var Injector *do.RootScope
 
 func init() {
-	Injector = do.New()
+	Injector = New()
+}
+
+// New returns an injector with every provider registered, tests override
+// single services with do.OverrideValue.
+func New() *do.RootScope {
+	i := do.New()
 
 	// Jika ada dependensi, tolong tambahkan sesuai dengan hirarki
+	// Setup config
+	do.Provide[*config.Config](i, config.NewConfigInject)
+	do.Provide[*config.Reloader](i, config.NewReloaderInject)
+	do.Provide[*tracing.Provider](i, tracing.NewProviderInject)
+
 	// Setup client
-	envMode := os.Getenv("MODE")
-	fmt.Print("Mode :%s", envMode)
-	if envMode == "DEBUG" {
-		do.Provide[domain.StorageClient](Injector, storage.NewMockStorageClientInject)
-	} else {
-		do.Provide[domain.StorageClient](Injector, storage.NewS3StorageClientInject)
-	}
+	do.Provide[domain.StorageClient](i, storage.NewStorageClientInject)
+	do.Provide[auth.Service](i, auth.NewJWTServiceInject)
 
 	// Setup database connection
-	do.Provide[*pgxpool.Pool](Injector, database.NewUserRepositoryInject)
+	do.Provide[*database.DB](i, database.NewDBInject)
+	do.Provide[*pgxpool.Pool](i, database.NewPoolInject)
+	do.Provide[*database.ReplicaSet](i, database.NewReplicaSetInject)
+	do.Provide[database.DBTX](i, database.NewDBTXInject)
+	do.Provide[database.ReadDBTX](i, database.NewReadDBTXInject)
+	do.Provide[*database.TxManager](i, database.NewTxManagerInject)
+	// Setup cache
+	do.Provide[cache.Store](i, cache.NewStoreInject)
+	do.Provide[*migration.Status](i, migration.NewStatusInject)
 	// setup logger
-	do.Provide[logger.LogHandler](Injector, logger.NewlogHandlerInject)
+	do.Provide[logger.LogHandler](i, logger.NewlogHandlerInject)
 
 	// Setup repositories
 	// UserRepository
-	do.Provide[repository.UserRepository](Injector, repository.NewUserRepositoryInject)
-	do.Provide[repository.ActivityRepository](Injector, repository.NewActivityRepositoryInject)
+	do.Provide[repository.UserRepository](i, repository.NewUserRepositoryInject)
+	do.Provide[repository.ActivityRepository](i, repository.NewActivityRepositoryInject)
+	do.Provide[repository.FileReferenceRepository](i, repository.NewFileReferenceRepositoryInject)
+	do.Provide[repository.ActivityPartitionRepository](i, repository.NewActivityPartitionRepositoryInject)
+	do.Provide[repository.SeedRepository](i, repository.NewSeedRepositoryInject)
 
 	// Setup Services
-	do.Provide[service.UserService](Injector, service.NewUserServiceInject)
-	do.Provide[service.ActivityService](Injector, service.NewActivityServiceInject)
+	do.Provide[service.UserService](i, service.NewUserServiceInject)
+	do.Provide[service.ActivityService](i, service.NewActivityServiceInject)
+	do.Provide[*service.FileGCService](i, service.NewFileGCServiceInject)
+	do.Provide[*service.ActivityPartitionService](i, service.NewActivityPartitionServiceInject)
 
 	// Setup Handlers
-	do.Provide[handler.AuthorizationHandler](Injector, handler.NewHandlerInject)
-	do.Provide[handler.UserHandler](Injector, handler.NewUserHandlerInject)
-	do.Provide[handler.ActivityHandler](Injector, handler.NewActivityHandlerInject)
+	do.Provide[handler.AuthorizationHandler](i, handler.NewHandlerInject)
+	do.Provide[handler.UserHandler](i, handler.NewUserHandlerInject)
+	do.Provide[handler.ActivityHandler](i, handler.NewActivityHandlerInject)
+
+	return i
 }
@@ -1,6 +1,17 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// StoredFile describes a single object kept by a StorageClient.
+type StoredFile struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
 
 type StorageClient interface {
 	// PutFile puts a file to the storage.
@@ -16,6 +27,15 @@ type StorageClient interface {
 		fileContent []byte,
 		isPublic bool,
 	) (string, error)
+	// PutStream is PutFile for content read from body until EOF, without
+	// holding all of it in memory. A read error aborts the upload.
+	PutStream(
+		ctx context.Context,
+		key string,
+		mimeType string,
+		body io.Reader,
+		isPublic bool,
+	) (string, error)
 	// GetFileContent retrieves the content of a file from the storage.
 	// The key is the filename or path in the storage.
 	// It returns the content of the file on success, or an error on failure.
@@ -24,4 +44,10 @@ type StorageClient interface {
 	// The key is the filename or path in the storage.
 	// It returns the file's URL as a string.
 	GetUrl(key string) string
+	// ListFiles lists every file whose key starts with the given prefix.
+	// An empty prefix lists the whole storage.
+	ListFiles(ctx context.Context, prefix string) ([]StoredFile, error)
+	// DeleteFile removes a file from the storage.
+	// The key is the filename or path in the storage.
+	DeleteFile(ctx context.Context, key string) error
 }
@@ -1,10 +1,59 @@
 package dto
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// ResponseActivity times are RFC 3339 in the user's timezone.
 type ResponseActivity struct {
-	Id                string `json:"activityId"`
-	ActivityType      string `json:"activityType"`
-	DoneAt            string `json:"doneAt"`
-	DurationInMinutes int    `json:"durationInMinutes"`
-	CaloriesBurned    int    `json:"caloriesBurned"`
-	CreatedAt         string `json:"createdAt"`
+	Id                string    `json:"activityId"`
+	ActivityType      string    `json:"activityType"`
+	DoneAt            time.Time `json:"doneAt"`
+	DurationInMinutes int       `json:"durationInMinutes"`
+	CaloriesBurned    int       `json:"caloriesBurned"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+type ArchivedPartition struct {
+	Partition string `json:"partition"`
+	Rows      int64  `json:"rows"`
+	// Key is the storage key of the archive, empty when it was only dropped.
+	Key string `json:"key,omitempty"`
+}
+
+type PartitionMaintenanceReport struct {
+	Created []string            `json:"created"`
+	Dropped []ArchivedPartition `json:"dropped"`
+	Errors  []string            `json:"errors,omitempty"`
+}
+
+// RequestActivity is one activity to log, DoneAt is RFC 3339.
+type RequestActivity struct {
+	ActivityType      string `json:"activityType" validate:"required"`
+	DoneAt            string `json:"doneAt" validate:"required"`
+	DurationInMinutes int    `json:"durationInMinutes" validate:"required,min=1"`
+}
+
+// RequestActivityBatch holds the activities undecoded, so that one
+// malformed item is reported on its own instead of failing the batch.
+type RequestActivityBatch struct {
+	Activities []json.RawMessage `json:"activities"`
+	// AllOrNothing stores nothing when any activity is invalid.
+	AllOrNothing bool `json:"allOrNothing"`
+}
+
+// ActivityBatchResult is the outcome of the activity at Index, it has
+// either an ActivityId or Errors keyed by field.
+type ActivityBatchResult struct {
+	Index          int               `json:"index"`
+	ActivityId     string            `json:"activityId,omitempty"`
+	CaloriesBurned int               `json:"caloriesBurned,omitempty"`
+	Errors         map[string]string `json:"errors,omitempty"`
+}
+
+type ResponseActivityBatch struct {
+	Created int                   `json:"created"`
+	Failed  int                   `json:"failed"`
+	Results []ActivityBatchResult `json:"results"`
 }
@@ -0,0 +1,11 @@
+package dto
+
+type FileSweepReport struct {
+	DryRun         bool     `json:"dryRun"`
+	Scanned        int      `json:"scanned"`
+	Orphaned       int      `json:"orphaned"`
+	Deleted        int      `json:"deleted"`
+	ReclaimedBytes int64    `json:"reclaimedBytes"`
+	OrphanedKeys   []string `json:"orphanedKeys"`
+	Errors         []string `json:"errors,omitempty"`
+}
@@ -11,6 +11,10 @@ type UserRequestUpdate struct {
 	Email string `json:"email"`
 }
 
+type RequestUpdateTimezone struct {
+	Timezone string `json:"timezone" validate:"required,timezone"`
+}
+
 type RequestUpdateProfile struct {
 	Email           *string `json:"email" validate:"required,email"`
 	Name            *string `json:"name" validate:"required,min=4,max=52"`
@@ -34,4 +38,15 @@ type ResponseGetProfile struct {
 	Email      string  `json:"email"`
 	Name       *string `json:"name"`
 	ImageUri   *string `json:"imageUri"`
+	Timezone   string  `json:"timezone"`
+}
+
+type SeedReport struct {
+	Seed       uint64   `json:"seed"`
+	Until      string   `json:"until"`
+	Users      int64    `json:"users"`
+	Activities int64    `json:"activities"`
+	Partitions []string `json:"partitions"`
+	// Password logs in every seeded user
+	Password string `json:"password"`
 }
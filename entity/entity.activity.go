@@ -1,10 +1,13 @@
 package entity
 
+import "time"
+
 type Activity struct {
 	ActivityId        *string
+	UserId            *string
 	ActivityType      *string
-	DoneAt            *int64
+	DoneAt            *time.Time
 	DurationInMinutes *int64
 	CaloriesBurned    *int64
-	CreatedAt         *int64
+	CreatedAt         *time.Time
 }
@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type User struct {
 	Id           *string `json:"id"`
 	Email        *string `json:"email"`
@@ -11,6 +13,9 @@ type User struct {
 	Height       *int    `json:"height"`
 	Name         *string `json:"name"`
 	ImageUri     *string `json:"image_uri"`
-	UpdatedAt    int64   `json:"updated_at"`
-	CreatedAt    int64   `json:"created_at"`
+	// Timezone is an IANA name such as "Asia/Jakarta", date filters and
+	// times in responses use it.
+	Timezone  string    `json:"timezone"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedAt time.Time `json:"created_at"`
 }
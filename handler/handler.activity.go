@@ -1,14 +1,21 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/entity"
 	"github.com/TimDebug/FitByte/helper"
 	"github.com/TimDebug/FitByte/logger"
 	"github.com/TimDebug/FitByte/middleware"
+	"github.com/TimDebug/FitByte/repository"
 	"github.com/TimDebug/FitByte/service"
+	"github.com/TimDebug/FitByte/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/samber/do/v2"
 )
@@ -41,6 +48,15 @@ func GetCaloriesPerMinute(activityType ActivityType) (float64, error) {
 	}
 }
 
+// CaloriesBurned is the calories of minutes of activityType.
+func CaloriesBurned(activityType ActivityType, minutes int) (int, error) {
+	caloriesPerMinute, err := GetCaloriesPerMinute(activityType)
+	if err != nil {
+		return 0, err
+	}
+	return int(float64(minutes) * caloriesPerMinute), nil
+}
+
 func IsValidActivityType(activityType ActivityType) bool {
 	_, err := GetCaloriesPerMinute(activityType)
 	return err == nil
@@ -48,17 +64,19 @@ func IsValidActivityType(activityType ActivityType) bool {
 
 type ActivityHandler struct {
 	service service.ActivityService
+	cfg     config.ActivityConfig
 	logger  logger.Logger
 }
 
-func NewActivityHandler(service service.ActivityService, logger logger.Logger) *ActivityHandler {
-	return &ActivityHandler{service: service, logger: logger}
+func NewActivityHandler(service service.ActivityService, cfg config.ActivityConfig, logger logger.Logger) *ActivityHandler {
+	return &ActivityHandler{service: service, cfg: cfg, logger: logger}
 }
 
 func NewActivityHandlerInject(i do.Injector) (ActivityHandler, error) {
 	_service := do.MustInvoke[service.ActivityService](i)
+	_config := do.MustInvoke[*config.Config](i)
 	_logger := do.MustInvoke[logger.LogHandler](i)
-	return *NewActivityHandler(_service, &_logger), nil
+	return *NewActivityHandler(_service, _config.Activity, &_logger), nil
 }
 
 // List all available activities
@@ -70,8 +88,8 @@ func NewActivityHandlerInject(i do.Injector) (ActivityHandler, error) {
 // @Param limit query int false "limit query param"
 // @Param offset query int false "offset query param"
 // @Param name query string false "activity name"
-// @Param doneAtFrom query string false "done at from in ISO date"
-// @Param doneAtTo query string false "done at from in ISO date"
+// @Param doneAtFrom query string false "done at from, RFC 3339 or a date in the user's timezone"
+// @Param doneAtTo query string false "done at to, RFC 3339 or a date in the user's timezone"
 // @Param caloriesBurnedMin query int false "calories burned minimum"
 // @Param caloriesBurnedMax query int false "calories burned maximum"
 // @Param Authorization header string true "Bearer JWT token"
@@ -82,7 +100,7 @@ func NewActivityHandlerInject(i do.Injector) (ActivityHandler, error) {
 func (a *ActivityHandler) GetAll(ctx *gin.Context) {
 	id, err := middleware.GetUserIdFromContext(ctx)
 	if err != nil {
-		a.logger.Warn(err.Error(), helper.ActivityHandlerGetAll)
+		a.logger.For(ctx).Warn(err.Error(), helper.ActivityHandlerGetAll)
 		ctx.JSON(helper.GetErrorStatusCode(err), err)
 		return
 	}
@@ -94,15 +112,125 @@ func (a *ActivityHandler) GetAll(ctx *gin.Context) {
 	params["doneAtTo"] = ctx.DefaultQuery("doneAtTo", "")
 	params["caloriesBurnedMin"] = ctx.DefaultQuery("caloriesBurnedMin", "")
 	params["caloriesBurnedMax"] = ctx.DefaultQuery("caloriesBurnedMax", "")
-	response, err := a.service.GetAll(ctx, buildQueryParams(ctx, params))
+	loc, err := a.service.Location(ctx, id)
 	if err != nil {
-		a.logger.Error(err.Error(), helper.ActivityHandlerGetAll)
+		a.logger.For(ctx).Error(err.Error(), helper.ActivityHandlerGetAll)
+		ctx.JSON(helper.GetErrorStatusCode(err), err)
+		return
+	}
+	response, err := a.service.GetAll(ctx, buildFilter(ctx, params, loc))
+	if err != nil {
+		a.logger.For(ctx).Error(err.Error(), helper.ActivityHandlerGetAll)
 		ctx.JSON(helper.GetErrorStatusCode(err), err)
 		return
 	}
 	ctx.JSON(http.StatusOK, response)
 }
 
+// Log several activities at once
+// @Tags activity
+// @Summary Log a batch of activities
+// @Description Validates every activity and stores the valid ones together. With allOrNothing nothing is stored when any activity is invalid.
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Param request body dto.RequestActivityBatch true "activities to log"
+// @Success 200 {object} dto.ResponseActivityBatch "OK, per activity results"
+// @Failure 400 {object} dto.ResponseActivityBatch "Bad Request, or rejected by allOrNothing"
+// @Failure 401 {object} helper.Response{errors=helper.ErrorResponse} "Unauthorized"
+// @Failure 500 {object} helper.Response{errors=helper.ErrorResponse} "Server Error"
+// @Router /v1/activity/batch [POST]
+func (a *ActivityHandler) CreateBatch(ctx *gin.Context) {
+	id, err := middleware.GetUserIdFromContext(ctx)
+	if err != nil {
+		a.logger.For(ctx).Warn(err.Error(), helper.ActivityHandlerCreateBatch)
+		ctx.JSON(helper.GetErrorStatusCode(err), err)
+		return
+	}
+
+	var requestBody dto.RequestActivityBatch
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		a.logger.For(ctx).Warn(err.Error(), helper.ActivityHandlerCreateBatch)
+		ctx.JSON(http.StatusBadRequest, helper.NewResponse(nil, err))
+		return
+	}
+	if n := len(requestBody.Activities); n == 0 || n > a.cfg.BatchMaxItems {
+		err := helper.NewErrorResponse(http.StatusBadRequest, fmt.Sprintf("a batch holds 1 to %d activities", a.cfg.BatchMaxItems))
+		ctx.JSON(http.StatusBadRequest, helper.NewResponse(nil, err))
+		return
+	}
+
+	response := dto.ResponseActivityBatch{Results: make([]dto.ActivityBatchResult, len(requestBody.Activities))}
+	var activities []entity.Activity
+	var indexes []int
+	for i, raw := range requestBody.Activities {
+		response.Results[i].Index = i
+		activity, fieldErrors := newActivity(raw)
+		if fieldErrors != nil {
+			response.Results[i].Errors = fieldErrors
+			response.Failed++
+			continue
+		}
+		activities = append(activities, activity)
+		indexes = append(indexes, i)
+	}
+
+	if response.Failed > 0 && requestBody.AllOrNothing {
+		ctx.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if len(activities) > 0 {
+		ids, err := a.service.CreateBatch(ctx, id, activities)
+		if err != nil {
+			a.logger.For(ctx).Error(err.Error(), helper.ActivityHandlerCreateBatch)
+			ctx.JSON(helper.GetErrorStatusCode(err), err)
+			return
+		}
+		for j, i := range indexes {
+			response.Results[i].ActivityId = ids[j]
+			response.Results[i].CaloriesBurned = int(*activities[j].CaloriesBurned)
+		}
+		response.Created = len(ids)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// newActivity decodes and validates one activity of a batch and works out
+// the calories it burned, the errors are keyed by JSON field.
+func newActivity(raw json.RawMessage) (entity.Activity, map[string]string) {
+	var request dto.RequestActivity
+	if err := json.Unmarshal(raw, &request); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			return entity.Activity{}, map[string]string{typeErr.Field: "must be of type " + typeErr.Type.String()}
+		}
+		return entity.Activity{}, map[string]string{"activity": "must be a JSON object"}
+	}
+
+	fieldErrors := validation.ValidateActivity(request)
+	if fieldErrors == nil {
+		fieldErrors = make(map[string]string)
+	}
+	calories, err := CaloriesBurned(ActivityType(request.ActivityType), request.DurationInMinutes)
+	if err != nil && request.ActivityType != "" {
+		fieldErrors["activityType"] = "is not a known activity type"
+	}
+	doneAt, err := time.Parse(time.RFC3339, request.DoneAt)
+	if err != nil && request.DoneAt != "" {
+		fieldErrors["doneAt"] = "must be an RFC 3339 time"
+	}
+	if len(fieldErrors) > 0 {
+		return entity.Activity{}, fieldErrors
+	}
+
+	duration, caloriesBurned := int64(request.DurationInMinutes), int64(calories)
+	return entity.Activity{
+		ActivityType:      &request.ActivityType,
+		DoneAt:            &doneAt,
+		DurationInMinutes: &duration,
+		CaloriesBurned:    &caloriesBurned,
+	}, nil
+}
+
 func getQueryInt(ctx *gin.Context, key string, defaultValue int) int {
 	value, exists := ctx.GetQuery(key)
 	if !exists {
@@ -115,46 +243,63 @@ func getQueryInt(ctx *gin.Context, key string, defaultValue int) int {
 	return intValue
 }
 
-func buildQueryParams(ctx *gin.Context, params map[string]string) []interface{} {
-	limit := getQueryInt(ctx, "limit", 5)
-	offset := getQueryInt(ctx, "offset", 0)
-	activityType := params["activityType"]
-	doneAtFrom := params["doneAtFrom"]
-	doneAtTo := params["doneAtTo"]
-	caloriesBurnedMin := getQueryInt(ctx, "caloriesBurnedMin", 0)
-	caloriesBurnedMax := getQueryInt(ctx, "caloriesBurnedMax", 0)
-	id := params["id"]
+// parseDoneAt reads an RFC 3339 time, or a bare date taken as the start of
+// that day in loc.
+func parseDoneAt(value string, loc *time.Location) (time.Time, bool, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, false, nil
+	}
+	parsed, err := time.ParseInLocation(time.DateOnly, value, loc)
+	return parsed, true, err
+}
 
-	queryArgs := []interface{}{id, nil, nil, nil, nil, nil, limit, offset}
+func buildFilter(ctx *gin.Context, params map[string]string, loc *time.Location) repository.ActivityFilter {
+	filter := repository.ActivityFilter{
+		UserId:   params["id"],
+		Timezone: loc.String(),
+		Limit:    getQueryInt(ctx, "limit", 5),
+		Offset:   getQueryInt(ctx, "offset", 0),
+	}
+	// negative values fall back to the defaults, like unparsable ones
+	if filter.Limit < 0 {
+		filter.Limit = 5
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
 
 	// validate activityType
-	if activityType != "" && IsValidActivityType(ActivityType(activityType)) {
-		queryArgs[1] = activityType
+	if activityType := params["activityType"]; activityType != "" && IsValidActivityType(ActivityType(activityType)) {
+		filter.ActivityType = &activityType
 	}
 
 	// validate doneAtFrom
-	if doneAtFrom != "" {
-		if parsedDate, err := time.Parse(time.RFC3339, doneAtFrom); err == nil {
-			queryArgs[2] = parsedDate
+	if doneAtFrom := params["doneAtFrom"]; doneAtFrom != "" {
+		if parsedDate, _, err := parseDoneAt(doneAtFrom, loc); err == nil {
+			filter.DoneAtFrom = &parsedDate
 		}
 	}
 
 	// validate doneAtTo
-	if doneAtTo != "" {
-		if parsedDate, err := time.Parse(time.RFC3339, doneAtTo); err == nil {
-			queryArgs[3] = parsedDate
+	if doneAtTo := params["doneAtTo"]; doneAtTo != "" {
+		if parsedDate, dateOnly, err := parseDoneAt(doneAtTo, loc); err == nil {
+			if dateOnly {
+				// a date includes the whole of that day
+				parsedDate = parsedDate.AddDate(0, 0, 1).Add(-time.Microsecond)
+			}
+			filter.DoneAtTo = &parsedDate
 		}
 	}
 
 	// validate caloriesBurnedMin
-	if caloriesBurnedMin > 0 {
-		queryArgs[4] = caloriesBurnedMin
+	if caloriesBurnedMin := getQueryInt(ctx, "caloriesBurnedMin", 0); caloriesBurnedMin > 0 {
+		filter.CaloriesBurnedMin = &caloriesBurnedMin
 	}
 
 	// validate caloriesBurnedMax
-	if caloriesBurnedMax > 0 {
-		queryArgs[5] = caloriesBurnedMax
+	if caloriesBurnedMax := getQueryInt(ctx, "caloriesBurnedMax", 0); caloriesBurnedMax > 0 {
+		filter.CaloriesBurnedMax = &caloriesBurnedMax
 	}
 
-	return queryArgs
+	return filter
 }
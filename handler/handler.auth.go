@@ -47,7 +47,7 @@ func (h authHandler) Login(ctx *gin.Context) {
 	requestBody := new(dto.UserRequestPayload)
 
 	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
-		h.logger.Warn(err.Error(), helper.FunctionCaller("AuthHandler.Login"), &requestBody)
+		h.logger.For(ctx).Warn(err.Error(), helper.FunctionCaller("AuthHandler.Login"))
 		ctx.JSON(http.StatusBadRequest, helper.NewResponse(nil, err))
 		return
 	}
@@ -77,7 +77,7 @@ func (h authHandler) Register(ctx *gin.Context) {
 	requestBody := new(dto.UserRequestPayload)
 
 	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
-		h.logger.Warn(err.Error(), helper.FunctionCaller("AuthHandler.Register"), &requestBody)
+		h.logger.For(ctx).Warn(err.Error(), helper.FunctionCaller("AuthHandler.Register"))
 		ctx.JSON(http.StatusBadRequest, helper.NewResponse(nil, err))
 		return
 	}
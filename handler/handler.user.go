@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 
+	"github.com/TimDebug/FitByte/dto"
 	"github.com/TimDebug/FitByte/helper"
 	"github.com/TimDebug/FitByte/logger"
 	"github.com/TimDebug/FitByte/middleware"
@@ -13,6 +14,7 @@ import (
 
 type UserHandler interface {
 	Get(ctx *gin.Context)
+	UpdateTimezone(ctx *gin.Context)
 }
 
 type userHandler struct {
@@ -50,7 +52,42 @@ func (h userHandler) Get(ctx *gin.Context) {
 
 	response, err := h.service.GetProfile(ctx, id)
 	if err != nil {
-		h.logger.Warn(err.Error(), helper.FunctionCaller("UserHandler.GetProfile"), id)
+		h.logger.For(ctx).Warn(err.Error(), helper.FunctionCaller("UserHandler.GetProfile"), id)
+		ctx.JSON(helper.GetErrorStatusCode(err), helper.NewResponse(nil, err))
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Update the user's timezone
+// @Tags users
+// @Summary Update Timezone User
+// @Description Set the IANA timezone used for activity date filters and times
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer + user token"
+// @Param request body dto.RequestUpdateTimezone true "IANA timezone, e.g. Asia/Jakarta"
+// @Success 200 {object} helper.Response{data=dto.ResponseGetProfile} "OK"
+// @Failure 400 {object} helper.Response{errors=helper.ErrorResponse} "Bad Request"
+// @Failure 401 {object} helper.Response{errors=helper.ErrorResponse} "Unauthorization"
+// @Router /v1/user/timezone [PUT]
+func (h userHandler) UpdateTimezone(ctx *gin.Context) {
+	id, err := middleware.GetUserIdFromContext(ctx)
+	if err != nil {
+		ctx.JSON(helper.GetErrorStatusCode(err), helper.NewResponse(nil, err))
+		return
+	}
+
+	var requestBody dto.RequestUpdateTimezone
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		h.logger.For(ctx).Warn(err.Error(), helper.FunctionCaller("UserHandler.UpdateTimezone"))
+		ctx.JSON(http.StatusBadRequest, helper.NewResponse(nil, err))
+		return
+	}
+
+	response, err := h.service.UpdateTimezone(ctx, id, &requestBody)
+	if err != nil {
+		h.logger.For(ctx).Warn(err.Error(), helper.FunctionCaller("UserHandler.UpdateTimezone"), id)
 		ctx.JSON(helper.GetErrorStatusCode(err), helper.NewResponse(nil, err))
 		return
 	}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/samber/do/v2"
+)
+
+type fakeDependency struct {
+	err error
+}
+
+func (f *fakeDependency) HealthCheck(ctx context.Context) error {
+	return f.err
+}
+
+func TestCheckerReportsEveryCheck(t *testing.T) {
+	checker := NewChecker(50*time.Millisecond,
+		Check{Name: "ok", Run: func(ctx context.Context) error { return nil }},
+		Check{Name: "failing", Run: func(ctx context.Context) error { return errors.New("connection refused") }},
+		Check{Name: "hanging", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Run took %s, the timeout did not apply", elapsed)
+	}
+
+	if report.Healthy() {
+		t.Fatalf("report is healthy: %+v", report)
+	}
+	if report.Checks["ok"].Status != StatusUp {
+		t.Errorf("ok = %+v", report.Checks["ok"])
+	}
+	if got := report.Checks["failing"]; got.Status != StatusDown || got.Error != "connection refused" {
+		t.Errorf("failing = %+v", got)
+	}
+	if got := report.Checks["hanging"]; got.Status != StatusDown || got.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("hanging = %+v", got)
+	}
+}
+
+func TestServiceBuildsAndChecksProvider(t *testing.T) {
+	injector := do.New()
+	dependency := &fakeDependency{}
+	do.Provide(injector, func(i do.Injector) (*fakeDependency, error) {
+		return dependency, nil
+	})
+	checker := NewChecker(time.Second, Service[*fakeDependency]("fake", injector))
+
+	if report := checker.Run(context.Background()); !report.Healthy() {
+		t.Fatalf("report = %+v", report)
+	}
+
+	dependency.err = errors.New("unavailable")
+	report := checker.Run(context.Background())
+	if got := report.Checks["fake"]; got.Status != StatusDown || got.Error != "unavailable" {
+		t.Fatalf("fake = %+v", got)
+	}
+}
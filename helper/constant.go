@@ -20,6 +20,15 @@ const (
 	ActivityHandlerGetAll FunctionCaller = "ActivityHandler.GetAll"
 	ActivityServiceGetAll FunctionCaller = "ActivityService.GetAll"
 
+	ActivityHandlerCreateBatch FunctionCaller = "ActivityHandler.CreateBatch"
+	ActivityServiceCreateBatch FunctionCaller = "ActivityService.CreateBatch"
+
+	FileGCServiceSweep    FunctionCaller = "FileGCService.Sweep"
+	FileGCServiceSchedule FunctionCaller = "FileGCService.Schedule"
+
+	ActivityPartitionServiceMaintain FunctionCaller = "ActivityPartitionService.Maintain"
+	ActivityPartitionServiceSchedule FunctionCaller = "ActivityPartitionService.Schedule"
+
 	GenerateFromPassword FunctionCaller = "GenerateFromPassword"
 
 	UserHandler FunctionCaller = "UserHandler"
@@ -1,32 +1,180 @@
 package migration
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/database"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/pgx"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-const MIGRATION_FILE_PATH = "file://database/migrations"
+// Migrator applies the migrations embedded in the binary. Every change is
+// made while holding golang-migrate's Postgres advisory lock, so replicas
+// booting together apply each migration once and the others wait.
+type Migrator struct {
+	m *migrate.Migrate
+}
 
-func AutoMigrate() {
-	cfg := config.DatabaseMigrateUrl()
-	migrate, err := migrate.New(MIGRATION_FILE_PATH, cfg)
+func New(db config.DatabaseConfig, cfg config.MigrationConfig) (*Migrator, error) {
+	return NewFromURL(db.MigrateURL(), cfg)
+}
 
+// NewFromURL migrates the database at a pgx:// URL, e.g. with a search_path
+// parameter selecting another schema.
+func NewFromURL(databaseURL string, cfg config.MigrationConfig) (*Migrator, error) {
+	src, err := openSource()
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", src, databaseURL)
 	if err != nil {
-		message := fmt.Sprintf("Error creating migrate instance: %v", err)
-		panic(message)
+		return nil, fmt.Errorf("create migrate instance: %w", err)
+	}
+	// Waiting replicas give up after this, a long migration needs more
+	m.LockTimeout = cfg.LockTimeout
+	return &Migrator{m: m}, nil
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down reverts the last n applied migrations.
+func (m *Migrator) Down(n int) error {
+	if n < 1 {
+		return fmt.Errorf("down needs at least 1 step, got %d", n)
 	}
+	return ignoreNoChange(m.m.Steps(-n))
+}
+
+// Force records version as applied and clears the dirty flag without
+// running anything, after a failed migration was repaired by hand.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
 
-	if err := migrate.Up(); err != nil {
-		if err.Error() == "no change" {
-			fmt.Println("No new migrations to apply.")
-		} else {
-			message := fmt.Sprintf("Migration failed: %v", err)
-			panic(message)
+// Version is the applied version, 0 when nothing was applied yet.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// AutoMigrate applies pending migrations at boot.
+func AutoMigrate(db config.DatabaseConfig, cfg config.MigrationConfig) error {
+	m, err := New(db, cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+	version, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+	log.Printf("Database schema at version %d", version)
+	return nil
+}
+
+func openSource() (source.Driver, error) {
+	src, err := iofs.New(database.Migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("open migrations: %w", err)
+	}
+	return src, nil
+}
+
+// LatestVersion is the newest migration compiled into the binary.
+func LatestVersion() (uint, error) {
+	driver, err := openSource()
+	if err != nil {
+		return 0, err
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, fmt.Errorf("read migrations: %w", err)
+	}
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read migrations: %w", err)
+		}
+		version = next
+	}
+}
+
+var (
+	migrationFile = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+	migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// Create adds an empty up and down migration to dir, numbered after the
+// newest file already there, and returns their paths.
+func Create(dir string, name string) ([]string, error) {
+	if !migrationName.MatchString(name) {
+		return nil, fmt.Errorf("migration name %q must be snake_case", name)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var latest uint64
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err == nil && version > latest {
+			latest = version
+		}
+	}
+
+	prefix := fmt.Sprintf("%06d_%s", latest+1, name)
+	paths := []string{
+		filepath.Join(dir, prefix+".up.sql"),
+		filepath.Join(dir, prefix+".down.sql"),
+	}
+	for _, path := range paths {
+		header := fmt.Sprintf("-- %s, created %s\n", filepath.Base(path), time.Now().UTC().Format(time.DateOnly))
+		if err := os.WriteFile(path, []byte(header), 0o644); err != nil {
+			return nil, err
 		}
 	}
-	fmt.Println("Migration completed successfully!")
+	return paths, nil
 }
@@ -0,0 +1,58 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLatestVersionMatchesSourceTree(t *testing.T) {
+	entries, err := os.ReadDir(filepath.Join("..", "..", "database", "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var newest uint64
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		if version, err := strconv.ParseUint(prefix, 10, 64); err == nil && version > newest {
+			newest = version
+		}
+	}
+
+	latest, err := LatestVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(latest) != newest {
+		t.Fatalf("embedded latest version is %d, the newest file is %d", latest, newest)
+	}
+}
+
+func TestCreateNumbersAfterNewestFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"000001_init.up.sql", "000001_init.down.sql", "000007_users.up.sql", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := Create(dir, "add_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "000008_add_index.up.sql"), filepath.Join(dir, "000008_add_index.down.sql")}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("Create = %v, want %v", paths, want)
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := Create(dir, "Add Index"); err == nil {
+		t.Error("Create accepted a name that is not snake_case")
+	}
+}
@@ -0,0 +1,52 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/do/v2"
+)
+
+// Status compares the schema version recorded by golang-migrate with the
+// newest migration shipped with the binary.
+type Status struct {
+	db     *pgxpool.Pool
+	latest uint
+}
+
+func NewStatus(db *pgxpool.Pool) (*Status, error) {
+	latest, err := LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+	return &Status{db: db, latest: latest}, nil
+}
+
+func NewStatusInject(i do.Injector) (*Status, error) {
+	db := do.MustInvoke[*pgxpool.Pool](i)
+	return NewStatus(db)
+}
+
+// HealthCheck fails while migrations are pending or the last one failed
+// half way (dirty).
+func (s *Status) HealthCheck(ctx context.Context) error {
+	var version int64
+	var dirty bool
+	err := s.db.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("no migration applied, want version %d", s.latest)
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty, fix it and force the version", version)
+	}
+	if uint(version) < s.latest {
+		return fmt.Errorf("schema at version %d, want %d", version, s.latest)
+	}
+	return nil
+}
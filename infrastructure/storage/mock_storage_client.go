@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/TimDebug/FitByte/domain"
 	"github.com/samber/do/v2"
 )
 
+const mockUploadDir = "./.uploads"
+
 type MockStorageClient struct{}
 
 func (m MockStorageClient) PutFile(
@@ -26,10 +31,10 @@ func (m MockStorageClient) PutFile(
 	}
 
 	// Tentukan lokasi penyimpanan file
-	savePath := fmt.Sprintf("./.uploads/%s", key)
+	savePath := fmt.Sprintf("%s/%s", mockUploadDir, key)
 
 	// Buat direktori jika belum ada
-	if err := os.MkdirAll("./.uploads", os.ModePerm); err != nil {
+	if err := os.MkdirAll(filepath.Dir(savePath), os.ModePerm); err != nil {
 		return "", fmt.Errorf("failed to create directory: %w", err)
 	}
 
@@ -42,6 +47,38 @@ func (m MockStorageClient) PutFile(
 	return m.GetUrl(key), nil
 }
 
+func (m MockStorageClient) PutStream(
+	ctx context.Context,
+	key string,
+	mimeType string,
+	body io.Reader,
+	isPublic bool,
+) (string, error) {
+	if strings.Contains(key, "mock_failed") {
+		return "", errors.New("Failed to put file")
+	}
+
+	savePath := fmt.Sprintf("%s/%s", mockUploadDir, key)
+	if err := os.MkdirAll(filepath.Dir(savePath), os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Create(savePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Like S3, an aborted upload leaves nothing behind
+		os.Remove(savePath)
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	return m.GetUrl(key), nil
+}
+
 func (m MockStorageClient) GetFileContent(ctx context.Context, key string) ([]byte, error) {
 	if strings.Contains(key, "mock_failed") {
 		return nil, errors.New("Failed to get file content")
@@ -56,6 +93,73 @@ func (m MockStorageClient) GetUrl(key string) string {
 	return fmt.Sprintf("%s/%s", baseURL, key)
 }
 
+func (m MockStorageClient) ListFiles(ctx context.Context, prefix string) ([]domain.StoredFile, error) {
+	files := make([]domain.StoredFile, 0)
+	err := filepath.WalkDir(mockUploadDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		key, err := filepath.Rel(mockUploadDir, path)
+		if err != nil {
+			return err
+		}
+		key = filepath.ToSlash(key)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, domain.StoredFile{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		// Belum ada file yang pernah diupload
+		return files, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return files, nil
+}
+
+func (m MockStorageClient) DeleteFile(ctx context.Context, key string) error {
+	if strings.Contains(key, "mock_failed") {
+		return errors.New("Failed to delete file")
+	}
+
+	err := os.Remove(filepath.Join(mockUploadDir, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// HealthCheck fails when the upload directory exists but is not a directory.
+func (m MockStorageClient) HealthCheck(ctx context.Context) error {
+	info, err := os.Stat(mockUploadDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", mockUploadDir)
+	}
+	return nil
+}
+
 func NewMockStorageClient() domain.StorageClient {
 	return MockStorageClient{}
 }
@@ -6,9 +6,9 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"sync"
 
+	"github.com/TimDebug/FitByte/config"
 	"github.com/TimDebug/FitByte/domain"
 	"github.com/TimDebug/FitByte/infrastructure"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -20,14 +20,13 @@ import (
 )
 
 var (
-	AWS_REGION = os.Getenv("AWS_REGION")
-	AWS_BUCKET = os.Getenv("AWS_BUCKET")
-
 	s3StorageClientOnce     sync.Once
 	s3StorageClientInstance *S3StorageClient
 )
 
 type S3StorageClient struct {
+	bucket       string
+	region       string
 	s3Downloader *manager.Downloader
 	s3Uploader   *manager.Uploader
 	s3           *s3.Client
@@ -42,17 +41,12 @@ func (s S3StorageClient) PutFile(
 	isPublic bool,
 ) (string, error) {
 	input := &s3.PutObjectInput{
-		Bucket:        aws.String(AWS_BUCKET),
+		Bucket:        aws.String(s.bucket),
 		Key:           aws.String(key),
 		Body:          bytes.NewReader(fileContent),
 		ContentLength: aws.Int64(int64(len(fileContent))),
 		ContentType:   aws.String(mimeType),
-		ACL: func() types.ObjectCannedACL {
-			if isPublic {
-				return types.ObjectCannedACLPublicRead
-			}
-			return types.ObjectCannedACLPrivate
-		}(),
+		ACL:           cannedACL(isPublic),
 	}
 	_, err := s.s3.PutObject(ctx, input)
 	if err != nil {
@@ -62,9 +56,38 @@ func (s S3StorageClient) PutFile(
 	return s.GetUrl(key), nil
 }
 
+// PutStream uploads body in parts, so its size need not be known up front.
+func (s S3StorageClient) PutStream(
+	ctx context.Context,
+	key string,
+	mimeType string,
+	body io.Reader,
+	isPublic bool,
+) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(mimeType),
+		ACL:         cannedACL(isPublic),
+	}
+	if _, err := s.s3Uploader.Upload(ctx, input); err != nil {
+		return "", err
+	}
+
+	return s.GetUrl(key), nil
+}
+
+func cannedACL(isPublic bool) types.ObjectCannedACL {
+	if isPublic {
+		return types.ObjectCannedACLPublicRead
+	}
+	return types.ObjectCannedACLPrivate
+}
+
 func (s S3StorageClient) GetFileContent(ctx context.Context, key string) ([]byte, error) {
 	input := &s3.GetObjectInput{
-		Bucket: aws.String(AWS_BUCKET),
+		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
 	}
 	output, err := s.s3.GetObject(ctx, input)
@@ -89,20 +112,64 @@ func (s S3StorageClient) GetFileContent(ctx context.Context, key string) ([]byte
 func (s S3StorageClient) GetUrl(key string) string {
 	return fmt.Sprintf(
 		"https://%s.s3.%s.amazonaws.com/%s",
-		AWS_BUCKET,
-		AWS_REGION,
+		s.bucket,
+		s.region,
 		key,
 	)
 }
 
-func NewS3StorageClient() domain.StorageClient {
+func (s S3StorageClient) ListFiles(ctx context.Context, prefix string) ([]domain.StoredFile, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+	}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	files := make([]domain.StoredFile, 0)
+	paginator := s3.NewListObjectsV2Paginator(s.s3, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			files = append(files, domain.StoredFile{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+
+	return files, nil
+}
+
+func (s S3StorageClient) DeleteFile(ctx context.Context, key string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+	_, err := s.s3.DeleteObject(ctx, input)
+	return err
+}
+
+// HealthCheck verifies the bucket exists and the credentials can reach it.
+func (s S3StorageClient) HealthCheck(ctx context.Context) error {
+	_, err := s.s3.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.bucket)})
+	return err
+}
+
+func NewS3StorageClient(cfg config.AWSConfig) domain.StorageClient {
 	s3StorageClientOnce.Do(func() {
-		sdkConfig := infrastructure.NewAws()
+		sdkConfig := infrastructure.NewAws(cfg)
 		_s3 := s3.NewFromConfig(sdkConfig)
 		downloader := manager.NewDownloader(_s3)
 		uploader := manager.NewUploader(_s3)
 		_sts := sts.NewFromConfig(sdkConfig)
 		s3StorageClientInstance = &S3StorageClient{
+			bucket:       cfg.Bucket,
+			region:       cfg.Region,
 			s3Downloader: downloader,
 			s3Uploader:   uploader,
 			s3:           _s3,
@@ -113,5 +180,23 @@ func NewS3StorageClient() domain.StorageClient {
 }
 
 func NewS3StorageClientInject(i do.Injector) (domain.StorageClient, error) {
-	return NewS3StorageClient(), nil
+	cfg := do.MustInvoke[*config.Config](i)
+	return NewS3StorageClient(cfg.AWS), nil
+}
+
+// NewStorageClientInject uses the local mock storage in DEBUG mode and S3
+// otherwise.
+func NewStorageClientInject(i do.Injector) (domain.StorageClient, error) {
+	cfg := do.MustInvoke[*config.Config](i)
+	var client domain.StorageClient
+	var err error
+	if cfg.Server.Mode == config.ModeDebug {
+		client, err = NewMockStorageClientInject(i)
+	} else {
+		client, err = NewS3StorageClientInject(i)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewTracedStorageClient(client), nil
 }
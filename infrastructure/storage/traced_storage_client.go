@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/TimDebug/FitByte/domain"
+	"github.com/TimDebug/FitByte/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedStorageClient wraps a StorageClient with a span per remote call.
+type TracedStorageClient struct {
+	next domain.StorageClient
+}
+
+func NewTracedStorageClient(next domain.StorageClient) TracedStorageClient {
+	return TracedStorageClient{next: next}
+}
+
+func (t TracedStorageClient) PutFile(
+	ctx context.Context,
+	key string,
+	mimeType string,
+	fileContent []byte,
+	isPublic bool,
+) (url string, err error) {
+	ctx, span := tracing.Start(ctx, "storage PutFile",
+		attribute.String("storage.key", key),
+		attribute.Int("storage.size", len(fileContent)),
+	)
+	defer func() { tracing.End(span, err) }()
+	return t.next.PutFile(ctx, key, mimeType, fileContent, isPublic)
+}
+
+func (t TracedStorageClient) PutStream(
+	ctx context.Context,
+	key string,
+	mimeType string,
+	body io.Reader,
+	isPublic bool,
+) (url string, err error) {
+	ctx, span := tracing.Start(ctx, "storage PutStream", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+	return t.next.PutStream(ctx, key, mimeType, body, isPublic)
+}
+
+func (t TracedStorageClient) GetFileContent(ctx context.Context, key string) (content []byte, err error) {
+	ctx, span := tracing.Start(ctx, "storage GetFileContent", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+	return t.next.GetFileContent(ctx, key)
+}
+
+func (t TracedStorageClient) GetUrl(key string) string {
+	return t.next.GetUrl(key)
+}
+
+func (t TracedStorageClient) ListFiles(ctx context.Context, prefix string) (files []domain.StoredFile, err error) {
+	ctx, span := tracing.Start(ctx, "storage ListFiles", attribute.String("storage.prefix", prefix))
+	defer func() {
+		span.SetAttributes(attribute.Int("storage.files", len(files)))
+		tracing.End(span, err)
+	}()
+	return t.next.ListFiles(ctx, prefix)
+}
+
+func (t TracedStorageClient) DeleteFile(ctx context.Context, key string) (err error) {
+	ctx, span := tracing.Start(ctx, "storage DeleteFile", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+	return t.next.DeleteFile(ctx, key)
+}
+
+// HealthCheck forwards to the wrapped client so /readyz still checks it.
+func (t TracedStorageClient) HealthCheck(ctx context.Context) error {
+	if checker, ok := t.next.(interface{ HealthCheck(context.Context) error }); ok {
+		return checker.HealthCheck(ctx)
+	}
+	return nil
+}
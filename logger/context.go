@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the request-scoped logger l.
+func NewContext(ctx context.Context, l *LogHandler) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the request-scoped logger stored by NewContext.
+func FromContext(ctx context.Context) (*LogHandler, bool) {
+	l, ok := ctx.Value(contextKey{}).(*LogHandler)
+	return l, ok
+}
+
+// With returns a logger that adds the given key/value pairs to every entry.
+func (l *LogHandler) With(keysAndValues ...interface{}) *LogHandler {
+	scoped := *l
+	scoped.logger = l.logger.With(keysAndValues...)
+	return &scoped
+}
+
+func (l *LogHandler) For(ctx context.Context) Logger {
+	if scoped, ok := FromContext(ctx); ok {
+		return scoped
+	}
+	return l
+}
+
+// Log writes msg with structured key/value pairs at the given level, e.g.
+// for access log entries that have no calling function.
+func (l *LogHandler) Log(level zapcore.Level, msg string, keysAndValues ...interface{}) {
+	l.logger.Logw(level, msg, keysAndValues...)
+}
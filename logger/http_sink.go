@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/TimDebug/FitByte/config"
+)
+
+const httpSinkTimeout = 10 * time.Second
+
+// httpSink buffers encoded entries and POSTs them as newline-delimited JSON
+// once batchSize entries are pending, every flushInterval and on Sync. A
+// batch that can not be delivered is dropped, the failure goes to stderr
+// since it can not be logged through the failing pipeline.
+type httpSink struct {
+	url       string
+	client    *http.Client
+	batchSize int
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	pending int
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func newHttpSink(cfg config.LogHttpConfig) *httpSink {
+	s := &httpSink{
+		url:       cfg.URL,
+		client:    &http.Client{Timeout: httpSinkTimeout},
+		batchSize: cfg.BatchSize,
+		flush:     make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.run(cfg.FlushInterval)
+	return s
+}
+
+func (s *httpSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.buf.Write(p)
+	s.pending++
+	if s.pending >= s.batchSize {
+		select {
+		case s.flush <- struct{}{}:
+		default: // a flush is already due
+		}
+	}
+	return n, err
+}
+
+// Sync ships the pending entries right away.
+func (s *httpSink) Sync() error {
+	return s.send()
+}
+
+// Close ships the pending entries and stops the background flusher.
+func (s *httpSink) Close() error {
+	close(s.stop)
+	<-s.done
+	return nil
+}
+
+func (s *httpSink) run(interval time.Duration) {
+	defer close(s.done)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+		case <-s.flush:
+		case <-s.stop:
+			s.report(s.send())
+			return
+		}
+		s.report(s.send())
+	}
+}
+
+func (s *httpSink) send() error {
+	s.mu.Lock()
+	if s.pending == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	batch := bytes.Clone(s.buf.Bytes())
+	s.buf.Reset()
+	s.pending = 0
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpSinkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("log shipper responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *httpSink) report(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Dropped log batch: %v\n", err)
+	}
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levels holds the global minimum level and per-package overrides. The
+// package of an entry is the directory of the file that logged it, e.g.
+// "service" or "storage".
+type levels struct {
+	global zap.AtomicLevel
+
+	mu       sync.Mutex // serializes updates, readers load packages
+	packages atomic.Pointer[map[string]zapcore.Level]
+}
+
+func newLevels(global zap.AtomicLevel) *levels {
+	l := &levels{global: global}
+	l.packages.Store(&map[string]zapcore.Level{})
+	return l
+}
+
+// Enabled reports whether any package may log at level, the package itself
+// is only known once the caller is resolved in Write.
+func (l *levels) Enabled(level zapcore.Level) bool {
+	if l.global.Enabled(level) {
+		return true
+	}
+	for _, min := range *l.packages.Load() {
+		if level >= min {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *levels) enabledFor(pkg string, level zapcore.Level) bool {
+	if min, ok := (*l.packages.Load())[pkg]; ok {
+		return level >= min
+	}
+	return l.global.Enabled(level)
+}
+
+func (l *levels) set(pkg string, level string) error {
+	var parsed zapcore.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return err
+	}
+	l.update(func(packages map[string]zapcore.Level) { packages[pkg] = parsed })
+	return nil
+}
+
+func (l *levels) clear(pkg string) {
+	l.update(func(packages map[string]zapcore.Level) { delete(packages, pkg) })
+}
+
+// update swaps in a modified copy, so Enabled never takes a lock.
+func (l *levels) update(fn func(map[string]zapcore.Level)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := make(map[string]zapcore.Level, len(*l.packages.Load())+1)
+	for pkg, level := range *l.packages.Load() {
+		next[pkg] = level
+	}
+	fn(next)
+	l.packages.Store(&next)
+}
+
+func (l *levels) snapshot() map[string]string {
+	packages := *l.packages.Load()
+	out := make(map[string]string, len(packages))
+	for pkg, level := range packages {
+		out[pkg] = level.String()
+	}
+	return out
+}
+
+func callerPackage(caller zapcore.EntryCaller) string {
+	if !caller.Defined {
+		return ""
+	}
+	// runtime reports slash separated paths on every OS
+	return path.Base(path.Dir(caller.File))
+}
+
+// levelCore drops entries below the level of the package that logged them.
+type levelCore struct {
+	zapcore.Core
+	levels *levels
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *levelCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !c.levels.enabledFor(callerPackage(entry.Caller), entry.Level) {
+		return nil
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// SetPackageLevel overrides the global level for entries logged from pkg.
+func (l *LogHandler) SetPackageLevel(pkg string, level string) error {
+	return l.levels.set(pkg, level)
+}
+
+// ClearPackageLevel makes pkg follow the global level again.
+func (l *LogHandler) ClearPackageLevel(pkg string) {
+	l.levels.clear(pkg)
+}
+
+type levelsResponse struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+type levelRequest struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler serves the global level and the package overrides at GET /,
+// and sets or clears the override of a package at PUT and DELETE /{package}:
+//
+//	curl -X PUT -d '{"level":"debug"}' localhost:9090/admin/log/levels/service
+func (l *LogHandler) LevelHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, l.levelsResponse())
+	})
+	mux.HandleFunc("PUT /{package}", func(w http.ResponseWriter, r *http.Request) {
+		var body levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid body: %v", err)})
+			return
+		}
+		if err := l.SetPackageLevel(r.PathValue("package"), strings.TrimSpace(body.Level)); err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJson(w, http.StatusOK, l.levelsResponse())
+	})
+	mux.HandleFunc("DELETE /{package}", func(w http.ResponseWriter, r *http.Request) {
+		l.ClearPackageLevel(r.PathValue("package"))
+		writeJson(w, http.StatusOK, l.levelsResponse())
+	})
+	return mux
+}
+
+func (l *LogHandler) levelsResponse() levelsResponse {
+	return levelsResponse{Level: l.level.Level().String(), Packages: l.levels.snapshot()}
+}
+
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
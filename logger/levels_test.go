@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimDebug/FitByte/helper"
+)
+
+func TestPackageLevelOverridesGlobalLevel(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewWriterLogHandler(&buf)
+
+	handler.Debug("hidden", helper.FunctionCaller("test"))
+	if err := handler.SetPackageLevel("service", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	handler.Debug("other package", helper.FunctionCaller("test"))
+	if buf.Len() != 0 {
+		t.Fatalf("debug entries written at info:\n%s", buf.String())
+	}
+
+	// Entries logged from this file belong to the logger package
+	if err := handler.SetPackageLevel("logger", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	handler.Debug("visible", helper.FunctionCaller("test"))
+	if !strings.Contains(buf.String(), `"msg":"visible"`) {
+		t.Fatalf("debug entry missing after override:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "logger/levels_test.go") {
+		t.Errorf("caller is not the logging code:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := handler.SetPackageLevel("logger", "error"); err != nil {
+		t.Fatal(err)
+	}
+	handler.Warn("quiet", helper.FunctionCaller("test"))
+	if buf.Len() != 0 {
+		t.Fatalf("warn entry written above the package level:\n%s", buf.String())
+	}
+
+	if err := handler.SetPackageLevel("logger", "loud"); err == nil {
+		t.Error("SetPackageLevel accepted an unknown level")
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	handler := NewWriterLogHandler(&bytes.Buffer{})
+	server := httptest.NewServer(http.StripPrefix("/admin/log/levels", handler.LevelHandler()))
+	defer server.Close()
+
+	do := func(method, path, body string) (int, levelsResponse) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got levelsResponse
+		_ = json.NewDecoder(resp.Body).Decode(&got)
+		return resp.StatusCode, got
+	}
+
+	if status, got := do(http.MethodPut, "/admin/log/levels/service", `{"level":"debug"}`); status != http.StatusOK || got.Packages["service"] != "debug" {
+		t.Fatalf("PUT = %d %+v", status, got)
+	}
+	if status, _ := do(http.MethodPut, "/admin/log/levels/service", `{"level":"loud"}`); status != http.StatusBadRequest {
+		t.Fatalf("PUT with an unknown level = %d", status)
+	}
+	if status, got := do(http.MethodGet, "/admin/log/levels/", ""); status != http.StatusOK || got.Level != "info" || got.Packages["service"] != "debug" {
+		t.Fatalf("GET = %d %+v", status, got)
+	}
+	if status, got := do(http.MethodDelete, "/admin/log/levels/service", ""); status != http.StatusOK || len(got.Packages) != 0 {
+		t.Fatalf("DELETE = %d %+v", status, got)
+	}
+}
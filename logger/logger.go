@@ -2,11 +2,17 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/TimDebug/FitByte/config"
 	"github.com/TimDebug/FitByte/helper"
 	"github.com/samber/do/v2"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type Logger interface {
@@ -14,43 +20,109 @@ type Logger interface {
 	Error(msg string, function helper.FunctionCaller, data ...interface{})
 	Debug(msg string, function helper.FunctionCaller, data ...interface{})
 	Warn(msg string, function helper.FunctionCaller, data ...interface{})
+	// For returns the request-scoped logger stored in ctx, if any, so the
+	// entry carries the request id, user id, route and trace id.
+	For(ctx context.Context) Logger
 }
 
 type LogHandler struct {
 	logger *zap.SugaredLogger
+	level  zap.AtomicLevel
+	levels *levels
+	sink   io.Closer // nil when the writer is not owned
 }
 
-func NewlogHandler() *LogHandler {
-	lumberjackLogger := &lumberjack.Logger{
-		Filename:   "./logs/app.log",
-		MaxSize:    10, // Max megabytes before log is rotated
-		MaxBackups: 10,
-		MaxAge:     30,
-		Compress:   true,
+// NewlogHandler writes entries to the sinks of cfg, at Info until SetLevel
+// is called. Entries are redacted as described by cfg before they are
+// encoded.
+func NewlogHandler(cfg config.LogConfig) (*LogHandler, error) {
+	redactor, err := NewRedactor(cfg.RedactKeys, cfg.RedactPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	sinks, closer, err := openSinks(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := newLogHandler(sinks, cfg, redactor)
+	handler.sink = closer
+	for _, override := range cfg.PackageLevels {
+		pkg, level, _ := strings.Cut(override, "=")
+		if err := handler.SetPackageLevel(strings.TrimSpace(pkg), strings.TrimSpace(level)); err != nil {
+			_ = closer.Close()
+			return nil, fmt.Errorf("log package level %q: %w", override, err)
+		}
 	}
+	return handler, nil
+}
+
+// NewWriterLogHandler writes JSON entries to w, e.g. a buffer in tests, with
+// the default redaction.
+func NewWriterLogHandler(w io.Writer) *LogHandler {
+	redactor, _ := NewRedactor(nil, nil)
+	return newLogHandler(zapcore.AddSync(w), config.LogConfig{Format: config.LogFormatJson}, redactor)
+}
 
-	writeSyncer := zapcore.AddSync(lumberjackLogger)
+func newLogHandler(w zapcore.WriteSyncer, cfg config.LogConfig, redactor *Redactor) *LogHandler {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.TimeKey = "timestamp"
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		writeSyncer,
-		zapcore.InfoLevel,
-	)
+	var encoder zapcore.Encoder
+	switch cfg.Format {
+	case config.LogFormatConsole:
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	}
 
-	logger := zap.New(core, zap.AddCaller())
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	levels := newLevels(level)
+
+	// The sampler decides in Check, so it has to be the outermost core
+	var core zapcore.Core = zapcore.NewCore(encoder, w, levels)
+	core = &levelCore{Core: core, levels: levels}
+	core = newRedactingCore(core, redactor)
+	if cfg.SampleInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.SampleInitial, cfg.SampleThereafter)
+	}
+
+	// Skip the LogHandler method, the caller is the code that logs
+	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 	return &LogHandler{
 		logger: logger.Sugar(),
+		level:  level,
+		levels: levels,
 	}
 }
 
 func NewlogHandlerInject(i do.Injector) (LogHandler, error) {
-	logger := NewlogHandler()
+	cfg := do.MustInvoke[*config.Config](i)
+	logger, err := NewlogHandler(cfg.Log)
+	if err != nil {
+		return LogHandler{}, err
+	}
 	return *logger, nil
 }
 
+// SetLevel changes the minimum level of every copy of this handler, e.g. "debug".
+func (l *LogHandler) SetLevel(level string) error {
+	return l.level.UnmarshalText([]byte(level))
+}
+
+// Shutdown flushes buffered entries and closes the sinks. It has a value
+// receiver because the injector hands out LogHandler by value.
+func (l LogHandler) Shutdown() error {
+	_ = l.logger.Sync()
+	if l.sink == nil {
+		return nil
+	}
+	return l.sink.Close()
+}
+
 func (l *LogHandler) Info(msg string, function helper.FunctionCaller, data ...interface{}) {
 	l.logger.Infow(msg,
 		"called_by", function,
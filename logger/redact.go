@@ -0,0 +1,243 @@
+package logger
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	redacted       = "[REDACTED]"
+	maxRedactDepth = 8
+)
+
+// defaultRedactKeys are masked wherever they appear as a field, struct field
+// or map key, matched case-insensitively as a substring, e.g. password_hash.
+var defaultRedactKeys = []string{"password", "token", "authorization", "secret"}
+
+var emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+// Redactor masks credentials and personal data before entries are encoded.
+// Struct fields tagged `log:"redact"` are masked whatever their name.
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor masks the default keys plus keys, email addresses and every
+// match of patterns.
+func NewRedactor(keys []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{keys: append([]string{}, defaultRedactKeys...)}
+	for _, key := range keys {
+		r.keys = append(r.keys, strings.ToLower(key))
+	}
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, compiled)
+	}
+	return r, nil
+}
+
+func (r *Redactor) sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range r.keys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks email addresses, keeping the first letter and the domain,
+// and every match of the configured patterns.
+func (r *Redactor) String(s string) string {
+	s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+func (r *Redactor) field(f zapcore.Field) zapcore.Field {
+	if r.sensitiveKey(f.Key) {
+		return zap.String(f.Key, redacted)
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, r.String(f.String))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, r.String(err.Error()))
+		}
+	case zapcore.StringerType:
+		if stringer, ok := f.Interface.(fmt.Stringer); ok {
+			return zap.String(f.Key, r.String(stringer.String()))
+		}
+	case zapcore.ReflectType:
+		return zap.Any(f.Key, r.value(reflect.ValueOf(f.Interface), 0))
+	}
+	return f
+}
+
+// value turns v into maps, slices and scalars with every sensitive part
+// masked, the JSON encoder then prints the copy instead of v.
+func (r *Redactor) value(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxRedactDepth {
+		return "[TOO DEEP]"
+	}
+
+	if v.CanInterface() {
+		switch value := v.Interface().(type) {
+		case error:
+			return r.String(value.Error())
+		case time.Time:
+			return value
+		case json.Number:
+			// Decoded by marshaled, stays a number unless a pattern matches
+			if masked := r.String(string(value)); masked != string(value) {
+				return masked
+			}
+			return value
+		case json.Marshaler:
+			// e.g. json.RawMessage or secret.Value, redacted in the form
+			// they are encoded in
+			if v.Kind() != reflect.Pointer || !v.IsNil() {
+				return r.marshaled(value, depth)
+			}
+		case encoding.TextMarshaler:
+			if v.Kind() != reflect.Pointer || !v.IsNil() {
+				text, err := value.MarshalText()
+				if err != nil {
+					return redacted
+				}
+				return r.String(string(text))
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return r.value(v.Elem(), depth+1)
+	case reflect.String:
+		return r.String(v.String())
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Name
+			if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			if field.Tag.Get("log") == "redact" || r.sensitiveKey(name) {
+				out[name] = redacted
+				continue
+			}
+			out[name] = r.value(v.Field(i), depth+1)
+		}
+		return out
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Sprintf("[%s]", v.Type())
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if r.sensitiveKey(key) {
+				out[key] = redacted
+				continue
+			}
+			out[key] = r.value(iter.Value(), depth+1)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return redacted // raw bytes may hold anything, e.g. a request body
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = r.value(v.Index(i), depth+1)
+		}
+		return out
+	default:
+		if v.CanInterface() {
+			return v.Interface()
+		}
+		return nil
+	}
+}
+
+// marshaled decodes the JSON of value and redacts it like any other map or
+// slice, JSON that cannot be read is masked whole.
+func (r *Redactor) marshaled(value json.Marshaler, depth int) interface{} {
+	data, err := value.MarshalJSON()
+	if err != nil {
+		return redacted
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return redacted
+	}
+	return r.value(reflect.ValueOf(decoded), depth+1)
+}
+
+// redactingCore masks the message and the fields of every entry before the
+// wrapped core encodes them.
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func newRedactingCore(core zapcore.Core, redactor *Redactor) zapcore.Core {
+	return &redactingCore{Core: core, redactor: redactor}
+}
+
+func (c *redactingCore) redact(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = c.redactor.field(f)
+	}
+	return out
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redact(fields)), redactor: c.redactor}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.String(entry.Message)
+	return c.Core.Write(entry, c.redact(fields))
+}
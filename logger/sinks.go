@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TimDebug/FitByte/config"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// closers closes every sink, reporting all failures.
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var errs []error
+	for _, closer := range c {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// openSinks opens every configured sink, the returned closer releases the
+// ones that hold a file or connection.
+func openSinks(cfg config.LogConfig) (zapcore.WriteSyncer, io.Closer, error) {
+	writers := make([]zapcore.WriteSyncer, 0, len(cfg.Sinks))
+	opened := closers{}
+
+	for _, sink := range cfg.Sinks {
+		switch sink {
+		case config.LogSinkStdout:
+			writers = append(writers, zapcore.Lock(os.Stdout))
+		case config.LogSinkFile:
+			file := &lumberjack.Logger{
+				Filename:   cfg.File.Path,
+				MaxSize:    cfg.File.MaxSizeMB,
+				MaxBackups: cfg.File.MaxBackups,
+				MaxAge:     cfg.File.MaxAgeDays,
+				Compress:   cfg.File.Compress,
+			}
+			writers = append(writers, zapcore.AddSync(file))
+			opened = append(opened, file)
+		case config.LogSinkSyslog:
+			syslog, err := openSyslog(cfg.Syslog)
+			if err != nil {
+				_ = opened.Close()
+				return nil, nil, fmt.Errorf("syslog sink: %w", err)
+			}
+			writers = append(writers, zapcore.AddSync(syslog))
+			opened = append(opened, syslog)
+		case config.LogSinkHttp:
+			shipper := newHttpSink(cfg.Http)
+			writers = append(writers, shipper)
+			opened = append(opened, shipper)
+		default:
+			_ = opened.Close()
+			return nil, nil, fmt.Errorf("unknown log sink %q", sink)
+		}
+	}
+
+	return zapcore.NewMultiWriteSyncer(writers...), opened, nil
+}
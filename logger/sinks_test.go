@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/helper"
+)
+
+func TestHttpSinkShipsBatches(t *testing.T) {
+	batches := make(chan []byte, 4)
+	shipper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		batches <- body
+	}))
+	defer shipper.Close()
+
+	handler, err := NewlogHandler(config.LogConfig{
+		Sinks: []string{config.LogSinkHttp},
+		Http:  config.LogHttpConfig{URL: shipper.URL, BatchSize: 2, FlushInterval: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.Info("first", helper.FunctionCaller("test"))
+	handler.Info("second", helper.FunctionCaller("test"))
+	select {
+	case batch := <-batches:
+		lines := 0
+		scanner := bufio.NewScanner(bytes.NewReader(batch))
+		for scanner.Scan() {
+			if !json.Valid(scanner.Bytes()) {
+				t.Fatalf("line is not JSON: %q", scanner.Text())
+			}
+			lines++
+		}
+		if lines != 2 {
+			t.Fatalf("batch has %d entries, want 2:\n%s", lines, batch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("full batch was not shipped")
+	}
+
+	// Shutdown ships what is left
+	handler.Info("last", helper.FunctionCaller("test"))
+	if err := handler.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case batch := <-batches:
+		if !strings.Contains(string(batch), `"msg":"last"`) {
+			t.Fatalf("final batch = %s", batch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending entries were not shipped on shutdown")
+	}
+}
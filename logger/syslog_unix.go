@@ -0,0 +1,15 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"io"
+	"log/syslog"
+
+	"github.com/TimDebug/FitByte/config"
+)
+
+func openSyslog(cfg config.SyslogConfig) (io.WriteCloser, error) {
+	// The level is part of the encoded entry, every line is sent as info
+	return syslog.Dial(cfg.Network, cfg.Addr, syslog.LOG_INFO|syslog.LOG_LOCAL0, cfg.Tag)
+}
@@ -1,55 +1,97 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // timezones for hosts and images without zoneinfo
 
 	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/cmd"
+	"github.com/TimDebug/FitByte/config"
 	"github.com/TimDebug/FitByte/di"
 	"github.com/TimDebug/FitByte/infrastructure/migration"
+	"github.com/TimDebug/FitByte/logger"
+	"github.com/TimDebug/FitByte/service"
+	"github.com/samber/do/v2"
 
 	"github.com/TimDebug/FitByte/server"
 	_ "github.com/joho/godotenv/autoload"
 )
 
 func main() {
-	healthCheckDI()
+	if len(os.Args) > 1 {
+		if err := cmd.Run(os.Args[1:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
 
-	migration.AutoMigrate()
+	cfg, err := do.Invoke[*config.Config](di.Injector)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
 
-	cache.Initialize()
-	// Handle graceful shutdown
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
-	defer cache.Cache.Close()
+	if cfg.Migration.AutoMigrate {
+		if err := migration.AutoMigrate(cfg.Database, cfg.Migration); err != nil {
+			log.Fatalf("Auto migration failed: %v", err)
+		}
+	}
+
+	// Apply runtime settings now and whenever the config is reloaded
+	reloader := do.MustInvoke[*config.Reloader](di.Injector)
+	logHandler := do.MustInvoke[logger.LogHandler](di.Injector)
+	reloader.Subscribe(func(runtime config.RuntimeConfig) {
+		if err := logHandler.SetLevel(runtime.LogLevel); err != nil {
+			log.Printf("Invalid log level %q: %v", runtime.LogLevel, err)
+		}
+		cache.SetTtl(runtime.CacheTtl)
+	})
+	reloader.Start()
+
+	fileGC := do.MustInvoke[*service.FileGCService](di.Injector)
+	if cfg.FileGC.Enabled {
+		fileGC.Schedule(cfg.FileGC)
+	}
+	if cfg.Partition.Enabled {
+		do.MustInvoke[*service.ActivityPartitionService](di.Injector).Schedule(cfg.Partition)
+	}
 
+	srv := server.New()
+	serveErr := make(chan error, 1)
 	go func() {
-		<-sig
-		cache.Cache.Close()
-		os.Exit(0)
+		serveErr <- srv.Start()
 	}()
 
-	err := server.Start()
-	if err != nil {
-		log.Fatalln(err)
-	}
+	// Handle graceful shutdown
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 
-}
+	exitCode := 0
+	select {
+	case s := <-sig:
+		log.Printf("Received %s, shutting down", s)
+	case err := <-serveErr:
+		log.Printf("Server stopped: %v", err)
+		exitCode = 1
+	}
 
-func healthCheckDI() {
-	health := di.Injector.HealthCheck()
-	fmt.Printf("DI HealthCheck: %v\n", health)
-	isHealthy := true
-	for service, err := range health {
-		if err != nil {
-			fmt.Printf("Service %s is unhealthy: %v\n", service, err)
-			isHealthy = false
-		}
+	// Drain HTTP first, the handlers still need the services below
+	if err := srv.Shutdown(context.Background()); err != nil {
+		log.Printf("Failed to drain connections: %v", err)
+		exitCode = 1
 	}
-	if !isHealthy {
-		panic("DI is not healthy")
+
+	// Dependents are shut down before their dependencies, e.g. the workers
+	// before the pool and the logger
+	teardownCtx, cancelTeardown := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	if errs := di.Injector.ShutdownWithContext(teardownCtx); errs != nil && errs.Len() > 0 {
+		log.Println(errs)
+		exitCode = 1
 	}
+	cancelTeardown()
+
+	os.Exit(exitCode)
 }
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RistrettoCollector exposes the counters ristretto keeps when created with
+// Config.Metrics enabled.
+type RistrettoCollector struct {
+	metrics *ristretto.Metrics
+
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	evictions *prometheus.Desc
+	rejected  *prometheus.Desc
+	costAdded *prometheus.Desc
+}
+
+func NewRistrettoCollector(metrics *ristretto.Metrics) *RistrettoCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "memory_cache", name), help, nil, nil)
+	}
+	return &RistrettoCollector{
+		metrics:   metrics,
+		hits:      desc("hits_total", "Gets that found the key."),
+		misses:    desc("misses_total", "Gets that did not find the key."),
+		evictions: desc("evictions_total", "Keys evicted to make room."),
+		rejected:  desc("rejected_sets_total", "Sets dropped by the admission policy."),
+		costAdded: desc("cost_added_total", "Total cost (bytes) of the keys added."),
+	}
+}
+
+func (c *RistrettoCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *RistrettoCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(c.metrics.Hits()))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(c.metrics.Misses()))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(c.metrics.KeysEvicted()))
+	ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(c.metrics.SetsRejected()))
+	ch <- prometheus.MustNewConstMetric(c.costAdded, prometheus.CounterValue, float64(c.metrics.CostAdded()))
+}
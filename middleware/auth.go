@@ -7,11 +7,20 @@ import (
 	"strings"
 
 	"github.com/TimDebug/FitByte/auth"
+	"github.com/TimDebug/FitByte/database"
 	"github.com/TimDebug/FitByte/helper"
 	"github.com/gin-gonic/gin"
 )
 
-func Authorization(c *gin.Context) {
+// Authorization validates the bearer token with jwtService and stores the
+// user id in the context.
+func Authorization(jwtService auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorize(c, jwtService)
+	}
+}
+
+func authorize(c *gin.Context, jwtService auth.Service) {
 	authorizationHeader := c.GetHeader("Authorization")
 	if !strings.Contains(authorizationHeader, "Bearer") && !strings.Contains(authorizationHeader, "bearer") {
 		c.JSON(http.StatusUnauthorized, helper.NewResponse(nil, errors.New("the request is allowed for logged in")))
@@ -27,13 +36,15 @@ func Authorization(c *gin.Context) {
 		bearerToken = strings.Replace(authorizationHeader, "bearer ", "", -1)
 	}
 
-	id, err := auth.ParseToken(bearerToken)
+	id, err := jwtService.ParseToken(bearerToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, helper.NewResponse(nil, err))
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 	c.Set("user_id", id)
+	withUserId(c, id)
+	c.Request = c.Request.WithContext(database.WithSession(c.Request.Context(), id))
 	c.Next()
 }
 
@@ -1,12 +1,45 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"slices"
+	"sync/atomic"
 
-func EnableCORS(c *gin.Context) {
-	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-	c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-	c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Authorization, Accept, X-Requested-With")
-	c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+	"github.com/gin-gonic/gin"
+)
+
+// CORS answers cross origin requests for a set of allowed origins that can be
+// replaced at runtime. "*" allows every origin without credentials, only
+// origins listed by name may send cookies or authorization.
+type CORS struct {
+	allowedOrigins atomic.Pointer[[]string]
+}
+
+func NewCORS(allowedOrigins []string) *CORS {
+	c := &CORS{}
+	c.SetAllowedOrigins(allowedOrigins)
+	return c
+}
+
+func (m *CORS) SetAllowedOrigins(origins []string) {
+	origins = slices.Clone(origins)
+	m.allowedOrigins.Store(&origins)
+}
+
+func (m *CORS) Handle(c *gin.Context) {
+	origin := c.GetHeader("Origin")
+	allowed := *m.allowedOrigins.Load()
+
+	c.Writer.Header().Add("Vary", "Origin")
+	switch {
+	case origin == "":
+	case slices.Contains(allowed, origin):
+		c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		setCORSAllowed(c)
+	case slices.Contains(allowed, "*"):
+		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		setCORSAllowed(c)
+	}
 
 	if c.Request.Method == "OPTIONS" {
 		c.AbortWithStatus(204)
@@ -15,3 +48,8 @@ func EnableCORS(c *gin.Context) {
 
 	c.Next()
 }
+
+func setCORSAllowed(c *gin.Context) {
+	c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Authorization, Accept, X-Requested-With")
+	c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+}
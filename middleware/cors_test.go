@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSCredentialsOnlyForListedOrigins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewCORS([]string{"*", "https://app.example.com"}).Handle)
+	r.GET("/resource", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, tc := range []struct {
+		origin, allowOrigin, credentials string
+	}{
+		{"https://app.example.com", "https://app.example.com", "true"},
+		{"https://evil.example.com", "*", ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		req.Header.Set("Origin", tc.origin)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tc.allowOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tc.origin, got, tc.allowOrigin)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tc.credentials {
+			t.Errorf("%s: Access-Control-Allow-Credentials = %q, want %q", tc.origin, got, tc.credentials)
+		}
+	}
+}
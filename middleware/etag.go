@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/gin-gonic/gin"
+)
+
+const cacheControlPrivate = "private, no-cache"
+
+// bufferedWriter holds the response back so a validator can be computed
+// over the whole body before anything reaches the client.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int)              { w.status = code }
+func (w *bufferedWriter) WriteHeaderNow()                   {}
+func (w *bufferedWriter) Write(data []byte) (int, error)    { return w.body.Write(data) }
+func (w *bufferedWriter) WriteString(s string) (int, error) { return w.body.WriteString(s) }
+func (w *bufferedWriter) Status() int                       { return w.status }
+func (w *bufferedWriter) Size() int                         { return w.body.Len() }
+func (w *bufferedWriter) Written() bool                     { return w.body.Len() > 0 }
+
+func bufferResponse(ctx *gin.Context) *bufferedWriter {
+	writer := &bufferedWriter{ResponseWriter: ctx.Writer, status: http.StatusOK}
+	ctx.Writer = writer
+	ctx.Next()
+	ctx.Writer = writer.ResponseWriter
+	return writer
+}
+
+// ETag computes a strong ETag over the body of successful GET responses and
+// answers 304 Not Modified when it matches If-None-Match.
+func ETag(ctx *gin.Context) {
+	if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+		ctx.Next()
+		return
+	}
+
+	writer := bufferResponse(ctx)
+	if writer.status != http.StatusOK {
+		writeBuffered(ctx, writer.status, writer.body.Bytes())
+		return
+	}
+
+	body := writer.body.Bytes()
+	sum := sha256.Sum256(body)
+	writeWithETag(ctx, `"`+hex.EncodeToString(sum[:16])+`"`, body)
+}
+
+// HTTPCache derives the ETag of authenticated GET responses from the user's
+// cache namespace version, so a matching If-None-Match is answered without
+// running the handler. When storeResponses is set the whole body is kept in
+// the cache too. It must run after Authorization.
+//
+// The version only tracks the writes of every instance when the store is
+// shared, with an in-process store HTTPCache falls back to ETag.
+type HTTPCache struct {
+	store          cache.Store
+	namespace      *cache.UserNamespace
+	versioned      bool
+	storeResponses bool
+	ttl            time.Duration
+}
+
+func NewHTTPCache(store cache.Store, storeResponses bool, ttl time.Duration) *HTTPCache {
+	return &HTTPCache{
+		store:          store,
+		namespace:      cache.NewUserNamespace(store),
+		versioned:      cache.Shared(store),
+		storeResponses: storeResponses,
+		ttl:            ttl,
+	}
+}
+
+func (h *HTTPCache) Handle(ctx *gin.Context) {
+	if !h.versioned {
+		ETag(ctx)
+		return
+	}
+	if ctx.Request.Method != http.MethodGet {
+		ctx.Next()
+		return
+	}
+
+	userId, err := GetUserIdFromContext(ctx)
+	if err != nil {
+		ctx.Next()
+		return
+	}
+	version, err := h.namespace.Version(ctx, userId)
+	if err != nil {
+		// Without a version the response can not be validated, fall back to the body
+		ETag(ctx)
+		return
+	}
+
+	requestKey := fmt.Sprintf("%s:v%d:%s", userId, version, ctx.Request.URL.RequestURI())
+	sum := sha256.Sum256([]byte(requestKey))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		notModified(ctx, etag)
+		ctx.Abort()
+		return
+	}
+
+	cacheKey := fmt.Sprintf(cache.CacheHttpResponse, requestKey)
+	if h.storeResponses {
+		if body, found, err := h.store.Get(ctx, cacheKey); err == nil && found {
+			writeWithETag(ctx, etag, []byte(body))
+			ctx.Abort()
+			return
+		}
+	}
+
+	writer := bufferResponse(ctx)
+	if writer.status != http.StatusOK {
+		writeBuffered(ctx, writer.status, writer.body.Bytes())
+		return
+	}
+
+	body := writer.body.Bytes()
+	if h.storeResponses {
+		// A failed write only costs a cache miss on the next request
+		_ = h.store.Set(context.WithoutCancel(ctx), cacheKey, string(body), h.ttl)
+	}
+	writeWithETag(ctx, etag, body)
+}
+
+func writeWithETag(ctx *gin.Context, etag string, body []byte) {
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		notModified(ctx, etag)
+		return
+	}
+
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", cacheControlPrivate)
+	writeBuffered(ctx, http.StatusOK, body)
+}
+
+func notModified(ctx *gin.Context, etag string) {
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", cacheControlPrivate)
+	ctx.Writer.WriteHeader(http.StatusNotModified)
+	ctx.Writer.WriteHeaderNow()
+}
+
+func writeBuffered(ctx *gin.Context, status int, body []byte) {
+	if ctx.Writer.Header().Get("Content-Type") == "" {
+		ctx.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	ctx.Writer.WriteHeader(status)
+	ctx.Writer.WriteHeaderNow()
+	if ctx.Request.Method != http.MethodHead {
+		ctx.Writer.Write(body)
+	}
+}
+
+// etagMatches implements the weak comparison If-None-Match asks for.
+func etagMatches(header string, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
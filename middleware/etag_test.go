@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func newETagTestRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/resource", handlers...)
+	return r
+}
+
+func doGet(r http.Handler, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/resource?limit=5", nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestETagConditionalGet(t *testing.T) {
+	r := newETagTestRouter(ETag, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"email": "jane@example.com"})
+	})
+
+	first := doGet(r, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.Len() == 0 {
+		t.Fatalf("first response = %d, etag %q, body %q", first.Code, etag, first.Body.String())
+	}
+	if got := first.Header().Get("Cache-Control"); got != cacheControlPrivate {
+		t.Fatalf("Cache-Control = %q", got)
+	}
+
+	second := doGet(r, etag)
+	if second.Code != http.StatusNotModified || second.Body.Len() != 0 {
+		t.Fatalf("conditional response = %d with body %q, want empty 304", second.Code, second.Body.String())
+	}
+
+	stale := doGet(r, `"something-else"`)
+	if stale.Code != http.StatusOK {
+		t.Fatalf("mismatching If-None-Match = %d, want 200", stale.Code)
+	}
+}
+
+func TestETagSkipsErrors(t *testing.T) {
+	r := newETagTestRouter(ETag, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+	})
+
+	rec := doGet(r, "")
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("ETag") != "" {
+		t.Fatalf("error response = %d, etag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestHTTPCacheUsesNamespaceVersion(t *testing.T) {
+	server := miniredis.RunT(t)
+	store, err := cache.NewRedisStore(server.Addr(), "", 0)
+	if err != nil {
+		t.Fatalf("NewRedisStore: %v", err)
+	}
+	defer store.Close()
+
+	calls := 0
+	httpCache := NewHTTPCache(store, false, time.Minute)
+	setUser := func(ctx *gin.Context) { ctx.Set("user_id", "user-1") }
+	r := newETagTestRouter(setUser, httpCache.Handle, func(ctx *gin.Context) {
+		calls++
+		ctx.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	etag := doGet(r, "").Header().Get("ETag")
+	if rec := doGet(r, etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("conditional response = %d, want 304", rec.Code)
+	}
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, a matching version must skip it", calls)
+	}
+
+	if err := cache.NewUserNamespace(store).Bump(context.Background(), "user-1"); err != nil {
+		t.Fatalf("Bump: %v", err)
+	}
+	rec := doGet(r, etag)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("after a write = %d with etag %q, want a fresh 200", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestHTTPCacheHashesTheBodyWithAMemoryStore(t *testing.T) {
+	store, err := cache.NewMemoryStore(cache.MaxCacheSize)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+	defer store.Close()
+
+	// Another instance changed the data, this one never saw the write
+	email := "jane@example.com"
+	httpCache := NewHTTPCache(store, true, time.Minute)
+	setUser := func(ctx *gin.Context) { ctx.Set("user_id", "user-1") }
+	r := newETagTestRouter(setUser, httpCache.Handle, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"email": email})
+	})
+
+	etag := doGet(r, "").Header().Get("ETag")
+	if rec := doGet(r, etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("conditional response = %d, want 304", rec.Code)
+	}
+	email = "john@example.com"
+	rec := doGet(r, etag)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("after a write elsewhere = %d with etag %q, want a fresh 200", rec.Code, rec.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimDebug/FitByte/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+func requestDurationCount(t *testing.T, labels map[string]string) uint64 {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "fitbyte_http_request_duration_seconds" {
+			continue
+		}
+	metric:
+		for _, m := range family.GetMetric() {
+			for _, pair := range m.GetLabel() {
+				if labels[pair.GetName()] != pair.GetValue() {
+					continue metric
+				}
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
+
+func TestMetricsLabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Metrics)
+	r.GET("/users/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := requestDurationCount(t, map[string]string{"method": "GET", "route": "/users/:id", "status": "204"}); got != 2 {
+		t.Errorf("/users/:id observations = %d, want 2", got)
+	}
+	if got := requestDurationCount(t, map[string]string{"method": "GET", "route": "unmatched", "status": "404"}); got != 1 {
+		t.Errorf("unmatched observations = %d, want 1", got)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/TimDebug/FitByte/helper"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+const maxRateLimitedClients = 10000
+
+// RateLimiter limits the request rate per client IP with a token bucket.
+// A limit of 0 lets every request through.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   rate.Limit
+	burst   int
+	clients map[string]*rateLimitedClient
+}
+
+type rateLimitedClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetLimit(perSecond, burst)
+	return l
+}
+
+// SetLimit replaces the limit. Clients keep the tokens they have, at most the
+// new burst, so a reload does not refill their buckets.
+func (l *RateLimiter) SetLimit(perSecond float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.clients == nil {
+		l.clients = make(map[string]*rateLimitedClient)
+	}
+	limit := rate.Limit(perSecond)
+	if limit == l.limit && burst == l.burst {
+		return
+	}
+	l.limit = limit
+	l.burst = burst
+	now := time.Now()
+	for _, client := range l.clients {
+		client.limiter.SetLimitAt(now, limit)
+		client.limiter.SetBurstAt(now, burst)
+	}
+}
+
+func (l *RateLimiter) Handle(c *gin.Context) {
+	if !l.allow(c.ClientIP(), time.Now()) {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, helper.NewResponse(nil, errors.New("too many requests")))
+		return
+	}
+	c.Next()
+}
+
+func (l *RateLimiter) allow(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit == 0 {
+		return true
+	}
+	client, ok := l.clients[ip]
+	if !ok {
+		if len(l.clients) >= maxRateLimitedClients {
+			l.evict(now)
+		}
+		client = &rateLimitedClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = client
+	}
+	client.lastSeen = now
+	return client.limiter.AllowN(now, 1)
+}
+
+// evict forgets the clients idle long enough for their bucket to be full
+// again, nothing is lost by dropping them. If that frees too little the
+// longest idle go until a tenth of maxRateLimitedClients is free.
+func (l *RateLimiter) evict(now time.Time) {
+	refill := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second))
+	for ip, client := range l.clients {
+		if now.Sub(client.lastSeen) >= refill {
+			delete(l.clients, ip)
+		}
+	}
+	excess := len(l.clients) - maxRateLimitedClients*9/10
+	if excess <= 0 {
+		return
+	}
+
+	ips := make([]string, 0, len(l.clients))
+	for ip := range l.clients {
+		ips = append(ips, ip)
+	}
+	slices.SortFunc(ips, func(a, b string) int {
+		return l.clients[a].lastSeen.Compare(l.clients[b].lastSeen)
+	})
+	for _, ip := range ips[:excess] {
+		delete(l.clients, ip)
+	}
+}
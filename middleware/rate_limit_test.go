@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiterEvictsIdleClientsOnly(t *testing.T) {
+	l := NewRateLimiter(1, 2)
+	start := time.Now()
+
+	// A busy client with an empty bucket
+	l.allow("busy", start)
+	l.allow("busy", start)
+	for i := 1; i < maxRateLimitedClients; i++ {
+		l.allow(fmt.Sprintf("idle-%d", i), start.Add(-time.Minute))
+	}
+
+	l.allow("new", start)
+	if _, ok := l.clients["idle-1"]; ok {
+		t.Fatal("a client idle past its refill was kept")
+	}
+	if l.allow("busy", start) {
+		t.Fatal("the busy client got a fresh bucket")
+	}
+}
+
+func TestRateLimiterKeepsBucketsOnReload(t *testing.T) {
+	l := NewRateLimiter(1, 2)
+	now := time.Now()
+	l.allow("busy", now)
+	l.allow("busy", now)
+
+	l.SetLimit(1, 2)
+	if l.allow("busy", now) {
+		t.Fatal("reloading the same limit refilled the bucket")
+	}
+	l.SetLimit(1, 5)
+	if l.allow("busy", now) {
+		t.Fatal("raising the burst refilled the bucket")
+	}
+	if l.clients["busy"].limiter.Burst() != 5 {
+		t.Fatalf("burst = %d, want 5", l.clients["busy"].limiter.Burst())
+	}
+}
+
+func TestRateLimiterIgnoresForwardedForFromUntrustedPeers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	r.Use(NewRateLimiter(1, 1).Handle)
+	r.GET("/resource", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("request %d = %d, want %d", i, rec.Code, want)
+		}
+	}
+}
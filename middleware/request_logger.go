@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/TimDebug/FitByte/logger"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	RequestIdHeader = "X-Request-ID"
+	maxRequestIdLen = 128
+)
+
+// RequestLogger tags the request with the incoming X-Request-ID, or a new
+// one, and echoes it in the response. It stores a logger carrying the
+// request id, route and trace id in the request context, services get it
+// with LogHandler.For, and writes one access log entry per request.
+func RequestLogger(root *logger.LogHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestId := c.GetHeader(RequestIdHeader)
+		if !validRequestId(requestId) {
+			requestId = newRequestId()
+		}
+		c.Header(RequestIdHeader, requestId)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		fields := []interface{}{"request_id", requestId, "route", route}
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.HasTraceID() {
+			fields = append(fields, "trace_id", span.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), root.With(fields...)))
+
+		c.Next()
+
+		// Authorization may have added the user id to the logger
+		requestLogger, _ := logger.FromContext(c.Request.Context())
+		status := c.Writer.Status()
+		level := zapcore.InfoLevel
+		switch {
+		case status >= 500:
+			level = zapcore.ErrorLevel
+		case status >= 400:
+			level = zapcore.WarnLevel
+		}
+		requestLogger.Log(level, "request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+			"user_agent", c.Request.UserAgent(),
+		)
+	}
+}
+
+// withUserId adds the authenticated user to the request-scoped logger.
+func withUserId(c *gin.Context, userId string) {
+	if requestLogger, ok := logger.FromContext(c.Request.Context()); ok {
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), requestLogger.With("user_id", userId)))
+	}
+}
+
+// validRequestId accepts caller supplied ids of printable ASCII only, they
+// end up in every log line.
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
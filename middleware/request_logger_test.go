@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimDebug/FitByte/helper"
+	"github.com/TimDebug/FitByte/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeTokenParser struct{}
+
+func (fakeTokenParser) GenerateToken(userID string) (string, error) { return userID, nil }
+func (fakeTokenParser) ParseToken(token string) (string, error)     { return token, nil }
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	lines := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestRequestLoggerScopesServiceLogsToTheRequest(t *testing.T) {
+	var buf bytes.Buffer
+	root := logger.NewWriterLogHandler(&buf)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(RequestLogger(root))
+	r.GET("/v1/user", Authorization(fakeTokenParser{}), func(ctx *gin.Context) {
+		root.For(ctx).Info("loading profile", helper.FunctionCaller("test"))
+		ctx.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/user", nil)
+	req.Header.Set("Authorization", "Bearer user-42")
+	req.Header.Set(RequestIdHeader, "req-1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIdHeader); got != "req-1" {
+		t.Fatalf("%s = %q, want the incoming id echoed", RequestIdHeader, got)
+	}
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want service and access log:\n%s", len(lines), buf.String())
+	}
+	for _, entry := range lines {
+		if entry["request_id"] != "req-1" || entry["user_id"] != "user-42" || entry["route"] != "/v1/user" {
+			t.Errorf("entry misses request fields: %v", entry)
+		}
+	}
+	if access := lines[1]; access["msg"] != "request" || access["status"] != float64(http.StatusOK) {
+		t.Errorf("access log = %v", access)
+	}
+}
+
+func TestRequestLoggerGeneratesInvalidOrMissingIds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestLogger(logger.NewWriterLogHandler(&bytes.Buffer{})))
+	r.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for _, incoming := range []string{"", "has spaces\nand newline", strings.Repeat("a", maxRequestIdLen+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if incoming != "" {
+			req.Header.Set(RequestIdHeader, incoming)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(RequestIdHeader)
+		if got == "" || got == incoming || len(got) != 32 {
+			t.Errorf("incoming %q: %s = %q, want a generated id", incoming, RequestIdHeader, got)
+		}
+	}
+}
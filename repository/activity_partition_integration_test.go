@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m))
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// planExecutions is how often scannedTables explains a statement, Postgres
+// may switch a prepared statement to its generic plan after five.
+const planExecutions = 7
+
+// scannedTables prepares the list query of filter the way pgx caches it and
+// explains planExecutions executions of it. It returns every table the plans
+// read, failing when they differ.
+func scannedTables(t *testing.T, db *pgtest.DB, filter ActivityFilter) []string {
+	t.Helper()
+	ctx := context.Background()
+	query, args := activityListQuery(filter)
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "PREPARE list AS "+query); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Exec(ctx, "DEALLOCATE list")
+
+	literals := make([]string, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case time.Time:
+			literals[i] = "'" + arg.Format(time.RFC3339Nano) + "'"
+		case string:
+			literals[i] = "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+		default:
+			literals[i] = fmt.Sprint(arg)
+		}
+	}
+	explain := "EXPLAIN (FORMAT JSON) EXECUTE list(" + strings.Join(literals, ", ") + ")"
+
+	var tables []string
+	for i := 0; i < planExecutions; i++ {
+		var plan []byte
+		if err := conn.QueryRow(ctx, explain).Scan(&plan); err != nil {
+			t.Fatal(err)
+		}
+		got := planTables(t, plan)
+		if i > 0 && !slices.Equal(got, tables) {
+			t.Fatalf("execution %d scans %v, the first scanned %v", i+1, got, tables)
+		}
+		tables = got
+	}
+	return tables
+}
+
+func planTables(t *testing.T, plan []byte) []string {
+	t.Helper()
+	var nodes []map[string]any
+	if err := json.Unmarshal(plan, &nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	var tables []string
+	var walk func(node map[string]any)
+	walk = func(node map[string]any) {
+		if name, ok := node["Relation Name"].(string); ok {
+			tables = append(tables, name)
+		}
+		children, _ := node["Plans"].([]any)
+		for _, child := range children {
+			walk(child.(map[string]any))
+		}
+	}
+	for _, node := range nodes {
+		walk(node["Plan"].(map[string]any))
+	}
+	slices.Sort(tables)
+	return slices.Compact(tables)
+}
+
+func TestActivityListPrunesPartitions(t *testing.T) {
+	db := pgtest.Open(t)
+	repo := NewActivityPartitionRepository(db.Pool)
+	ctx := context.Background()
+	for _, m := range []time.Month{time.January, time.February, time.March} {
+		if _, err := repo.Create(ctx, month(2025, m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	user := db.NewUser().Create(t)
+	for _, m := range []time.Month{time.January, time.February, time.March} {
+		db.NewActivity(user.Id).DoneAt(month(2025, m).Add(36 * time.Hour)).Create(t)
+	}
+
+	from, to := month(2025, time.February), month(2025, time.February).Add(27*24*time.Hour)
+	got := scannedTables(t, db, ActivityFilter{UserId: user.Id, DoneAtFrom: &from, DoneAtTo: &to, Limit: 5})
+	if want := []string{"activities_p2025_02"}; !slices.Equal(got, want) {
+		t.Errorf("February scans %v, want %v", got, want)
+	}
+
+	from, to = month(2025, time.January).Add(24*time.Hour), month(2025, time.February).Add(24*time.Hour)
+	got = scannedTables(t, db, ActivityFilter{UserId: user.Id, DoneAtFrom: &from, DoneAtTo: &to, Limit: 5})
+	if want := []string{"activities_p2025_01", "activities_p2025_02"}; !slices.Equal(got, want) {
+		t.Errorf("January to February scans %v, want %v", got, want)
+	}
+
+	got = scannedTables(t, db, ActivityFilter{UserId: user.Id, Limit: 5})
+	if !slices.Contains(got, "activities_default") || !slices.Contains(got, "activities_p2025_03") {
+		t.Errorf("without a range scans %v, want every partition", got)
+	}
+}
+
+func TestCreatePartitionMovesRowsOutOfDefault(t *testing.T) {
+	db := pgtest.Open(t)
+	repo := NewActivityPartitionRepository(db.Pool)
+	ctx := context.Background()
+	user := db.NewUser().Create(t)
+	id := db.NewActivity(user.Id).DoneAt(time.Date(2024, 6, 15, 7, 0, 0, 0, time.UTC)).Create(t)
+
+	partition, err := repo.Create(ctx, time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partition.Name != "activities_p2024_06" || !partition.Month.Equal(month(2024, time.June)) {
+		t.Fatalf("partition = %+v", partition)
+	}
+
+	var table string
+	if err := db.Pool.QueryRow(ctx, `SELECT tableoid::regclass::TEXT FROM activities WHERE id = $1`, id).Scan(&table); err != nil {
+		t.Fatal(err)
+	}
+	if table != partition.Name {
+		t.Fatalf("activity is stored in %s, want %s", table, partition.Name)
+	}
+
+	if _, err := repo.Create(ctx, month(2024, time.June)); err != nil {
+		t.Fatalf("creating an existing partition: %v", err)
+	}
+}
+
+func TestExportAndDropPartition(t *testing.T) {
+	db := pgtest.Open(t)
+	repo := NewActivityPartitionRepository(db.Pool)
+	ctx := context.Background()
+	partition, err := repo.Create(ctx, month(2023, time.March))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := db.NewUser().Create(t)
+	db.NewActivity(user.Id).Type("Yoga").DoneAt(month(2023, time.March).Add(time.Hour)).Create(t)
+	db.NewActivity(user.Id).Type("Running").DoneAt(month(2023, time.March).Add(48 * time.Hour)).Create(t)
+
+	if _, err := repo.Drop(ctx, partition, ""); err == nil {
+		t.Fatal("dropped an attached partition")
+	}
+	if err := repo.Detach(ctx, partition); err != nil {
+		t.Fatal(err)
+	}
+	partitions, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(partitions, ActivityPartition{Name: partition.Name, Month: partition.Month, Detached: true}) {
+		t.Fatalf("partitions = %+v, want %s detached", partitions, partition.Name)
+	}
+	partition.Detached = true
+
+	var csv bytes.Buffer
+	rows, err := repo.Export(ctx, partition, &csv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if rows != 2 || len(lines) != 3 || !strings.HasPrefix(lines[0], "id,user_id,done_at") {
+		t.Fatalf("exported %d rows:\n%s", rows, csv.String())
+	}
+
+	// Written between the export and the drop, it must not go with the
+	// partition
+	late := db.NewActivity(user.Id).Type("Walking").DoneAt(month(2023, time.March).Add(72 * time.Hour)).Create(t)
+
+	userIds, err := repo.Drop(ctx, partition, "archive/activities/2023-03.csv.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(userIds, []string{user.Id}) {
+		t.Fatalf("dropped activities of %v, want %v", userIds, []string{user.Id})
+	}
+	partitions, err = repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range partitions {
+		if p.Name == partition.Name {
+			t.Fatalf("%s is still listed", partition.Name)
+		}
+	}
+
+	var table string
+	if err := db.Pool.QueryRow(ctx, "SELECT tableoid::regclass::TEXT FROM activities WHERE id = $1", late).Scan(&table); err != nil {
+		t.Fatalf("the late activity is gone: %v", err)
+	}
+	if table != "activities_default" {
+		t.Fatalf("the late activity is in %s", table)
+	}
+	months, err := repo.DefaultMonths(ctx, month(2023, time.April))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(months, month(2023, time.March).Equal) {
+		t.Fatalf("default months %v, want March 2023", months)
+	}
+	refs := NewFileReferenceRepository(db.Pool)
+	referenced, err := refs.FilterReferenced(ctx, []string{"archive/activities/2023-03.csv.gz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(referenced) != 1 {
+		t.Fatal("the archive is not protected from the file GC")
+	}
+}
+
+func TestPartitionLockExcludesOtherInstances(t *testing.T) {
+	db := pgtest.Open(t)
+	repo := NewActivityPartitionRepository(db.Pool)
+	ctx := context.Background()
+
+	unlock, err := repo.Lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiting, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if _, err := repo.Lock(waiting); err == nil {
+		t.Fatal("a second instance took the held lock")
+	}
+
+	unlock()
+	unlock, err = repo.Lock(ctx)
+	if err != nil {
+		t.Fatalf("lock after unlock: %v", err)
+	}
+	unlock()
+}
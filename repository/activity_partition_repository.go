@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/do/v2"
+)
+
+const (
+	activityPartitionPrefix = "activities_p"
+	activityPartitionLayout = "2006_01"
+
+	// activityPartitionLock is the pg_advisory_lock key of maintenance,
+	// "fbpart" in ASCII.
+	activityPartitionLock int64 = 0x666270617274
+)
+
+// ActivityPartition is the partition of activities holding one month.
+type ActivityPartition struct {
+	Name string
+	// Month is the first instant of the month, in UTC.
+	Month time.Time
+	// Detached partitions no longer take rows, they are waiting to be
+	// archived and dropped.
+	Detached bool
+}
+
+// End is the first instant after the partition.
+func (p ActivityPartition) End() time.Time {
+	return p.Month.AddDate(0, 1, 0)
+}
+
+type ActivityPartitionRepository interface {
+	// Create adds the partition of the month of month unless it exists.
+	Create(ctx context.Context, month time.Time) (ActivityPartition, error)
+	// List returns the monthly partitions, attached or detached, oldest
+	// first.
+	List(ctx context.Context) ([]ActivityPartition, error)
+	// Detach stops partition from taking rows, later rows of its month go
+	// to activities_default.
+	Detach(ctx context.Context, partition ActivityPartition) error
+	// Export writes the rows of partition to w as CSV with a header and
+	// returns the number of rows.
+	Export(ctx context.Context, partition ActivityPartition, w io.Writer) (int64, error)
+	// Drop removes a detached partition and its rows and returns the users
+	// who had activities in it. A non-empty archiveKey is recorded as a file
+	// reference owned by the partition in the same transaction, so the file
+	// GC keeps the archive.
+	Drop(ctx context.Context, partition ActivityPartition, archiveKey string) (userIds []string, err error)
+	// DefaultMonths returns the months, in UTC, of the rows in
+	// activities_default done before before.
+	DefaultMonths(ctx context.Context, before time.Time) ([]time.Time, error)
+	// Lock waits until no other instance maintains the partitions and
+	// holds them until unlock is called.
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// activityPartitionRepository runs DDL and COPY, both need the pool
+// itself rather than DBTX.
+type activityPartitionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewActivityPartitionRepository(pool *pgxpool.Pool) ActivityPartitionRepository {
+	return &activityPartitionRepository{pool: pool}
+}
+
+func NewActivityPartitionRepositoryInject(i do.Injector) (ActivityPartitionRepository, error) {
+	pool := do.MustInvoke[*pgxpool.Pool](i)
+	return NewActivityPartitionRepository(pool), nil
+}
+
+func (r *activityPartitionRepository) Create(ctx context.Context, month time.Time) (ActivityPartition, error) {
+	var name string
+	err := r.pool.QueryRow(ctx, `SELECT create_activities_partition($1::DATE)`, month.Format(time.DateOnly)).Scan(&name)
+	if err != nil {
+		return ActivityPartition{}, fmt.Errorf("create partition of %s: %w", month.Format("2006-01"), err)
+	}
+	return parseActivityPartition(name)
+}
+
+func (r *activityPartitionRepository) List(ctx context.Context) ([]ActivityPartition, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT relname, relispartition
+		FROM pg_class
+		WHERE relkind = 'r'
+			AND relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = current_schema())
+			AND relname LIKE 'activities\_p%'
+		ORDER BY relname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []ActivityPartition
+	for rows.Next() {
+		var name string
+		var attached bool
+		if err := rows.Scan(&name, &attached); err != nil {
+			return nil, err
+		}
+		partition, err := parseActivityPartition(name)
+		if err != nil {
+			return nil, err
+		}
+		partition.Detached = !attached
+		partitions = append(partitions, partition)
+	}
+	return partitions, rows.Err()
+}
+
+func (r *activityPartitionRepository) Detach(ctx context.Context, partition ActivityPartition) error {
+	_, err := r.pool.Exec(ctx, "ALTER TABLE activities DETACH PARTITION "+pgx.Identifier{partition.Name}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("detach %s: %w", partition.Name, err)
+	}
+	return nil
+}
+
+func (r *activityPartitionRepository) DefaultMonths(ctx context.Context, before time.Time) ([]time.Time, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT date_trunc('month', done_at AT TIME ZONE 'UTC')
+		FROM activities_default
+		WHERE done_at < $1
+		ORDER BY 1
+	`, before)
+	if err != nil {
+		return nil, err
+	}
+	months, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+	if err != nil {
+		return nil, err
+	}
+	for i := range months {
+		months[i] = time.Date(months[i].Year(), months[i].Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return months, nil
+}
+
+func (r *activityPartitionRepository) Export(ctx context.Context, partition ActivityPartition, w io.Writer) (int64, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Conn().PgConn().CopyTo(ctx, w,
+		fmt.Sprintf("COPY %s TO STDOUT WITH (FORMAT csv, HEADER)", pgx.Identifier{partition.Name}.Sanitize()))
+	if err != nil {
+		return 0, fmt.Errorf("export %s: %w", partition.Name, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *activityPartitionRepository) Drop(ctx context.Context, partition ActivityPartition, archiveKey string) ([]string, error) {
+	if !partition.Detached {
+		return nil, fmt.Errorf("drop %s: the partition is attached", partition.Name)
+	}
+	table := pgx.Identifier{partition.Name}.Sanitize()
+	var userIds []string
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if archiveKey != "" {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO FileReferences (file_key, owner_table, owner_id)
+				VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING
+			`, archiveKey, FileOwnerActivityArchive, partition.Name)
+			if err != nil {
+				return err
+			}
+		}
+		rows, err := tx.Query(ctx, "SELECT DISTINCT user_id::TEXT FROM "+table)
+		if err != nil {
+			return fmt.Errorf("read users of %s: %w", partition.Name, err)
+		}
+		userIds, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return fmt.Errorf("read users of %s: %w", partition.Name, err)
+		}
+		if _, err := tx.Exec(ctx, "DROP TABLE "+table); err != nil {
+			return fmt.Errorf("drop %s: %w", partition.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userIds, nil
+}
+
+// Lock holds a session advisory lock on a connection of its own, which is
+// closed instead of returned to the pool when the unlock fails.
+func (r *activityPartitionRepository) Lock(ctx context.Context) (func(), error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, activityPartitionLock); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("lock partition maintenance: %w", err)
+	}
+	return func() {
+		ctx := context.WithoutCancel(ctx)
+		if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, activityPartitionLock); err != nil {
+			conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}, nil
+}
+
+func parseActivityPartition(name string) (ActivityPartition, error) {
+	month, err := time.Parse(activityPartitionLayout, strings.TrimPrefix(name, activityPartitionPrefix))
+	if err != nil || !strings.HasPrefix(name, activityPartitionPrefix) {
+		return ActivityPartition{}, fmt.Errorf("unexpected activities partition %q", name)
+	}
+	return ActivityPartition{Name: name, Month: month}, nil
+}
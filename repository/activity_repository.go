@@ -1,43 +1,94 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TimDebug/FitByte/database"
 	"github.com/TimDebug/FitByte/entity"
-	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/samber/do/v2"
 )
 
-type ActivityRepository struct {
-	db *pgxpool.Pool
+// ActivityFilter selects a user's activities, nil fields do not filter.
+type ActivityFilter struct {
+	UserId string `json:"userId"`
+	// Timezone is the user's, responses show times in it.
+	Timezone          string     `json:"timezone"`
+	ActivityType      *string    `json:"activityType"`
+	DoneAtFrom        *time.Time `json:"doneAtFrom"`
+	DoneAtTo          *time.Time `json:"doneAtTo"`
+	CaloriesBurnedMin *int       `json:"caloriesBurnedMin"`
+	CaloriesBurnedMax *int       `json:"caloriesBurnedMax"`
+	Limit             int        `json:"limit"`
+	Offset            int        `json:"offset"`
+}
+
+type ActivityRepository interface {
+	// GetAll returns the activities matching filter, most recent first.
+	GetAll(ctx context.Context, filter ActivityFilter) ([]entity.Activity, error)
+	// CreateBatch stores activities for userId in one round trip and
+	// returns their ids in order. Either every activity is stored or none.
+	CreateBatch(ctx context.Context, userId string, activities []entity.Activity) ([]string, error)
+}
+
+// activityListQuery only has predicates for the filters that are set. A
+// "$3 IS NULL OR done_at >= $3" form would prune partitions in a custom plan
+// only, once a prepared statement switches to its generic plan every month
+// is scanned.
+func activityListQuery(filter ActivityFilter) (string, []any) {
+	var query strings.Builder
+	args := []any{filter.UserId}
+	query.WriteString(`
+	SELECT
+		id, activity_type, done_at,
+		duration_in_minutes, calories_burned, created_at
+	FROM activities
+	WHERE user_id = $1`)
+	where := func(predicate string, arg any) {
+		args = append(args, arg)
+		fmt.Fprintf(&query, "\n\t\tAND "+predicate, len(args))
+	}
+	if filter.ActivityType != nil {
+		where("activity_type = $%d", *filter.ActivityType)
+	}
+	if filter.DoneAtFrom != nil {
+		where("done_at >= $%d", *filter.DoneAtFrom)
+	}
+	if filter.DoneAtTo != nil {
+		where("done_at <= $%d", *filter.DoneAtTo)
+	}
+	if filter.CaloriesBurnedMin != nil {
+		where("calories_burned >= $%d", *filter.CaloriesBurnedMin)
+	}
+	if filter.CaloriesBurnedMax != nil {
+		where("calories_burned <= $%d", *filter.CaloriesBurnedMax)
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	fmt.Fprintf(&query, "\n\tORDER BY done_at DESC\n\tLIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	return query.String(), args
 }
 
-func NewActivityRepository(db *pgxpool.Pool) ActivityRepository {
-	return ActivityRepository{db: db}
+type activityRepository struct {
+	db   database.DBTX
+	read database.ReadDBTX
+}
+
+func NewActivityRepository(db database.DBTX, read database.ReadDBTX) ActivityRepository {
+	return &activityRepository{db: db, read: read}
 }
 
 func NewActivityRepositoryInject(i do.Injector) (ActivityRepository, error) {
-	db := do.MustInvoke[*pgxpool.Pool](i)
-	return NewActivityRepository(db), nil
+	db := do.MustInvoke[database.DBTX](i)
+	read := do.MustInvoke[database.ReadDBTX](i)
+	return NewActivityRepository(db, read), nil
 }
 
-func (r *ActivityRepository) GetAll(
-	ctx *gin.Context,
-	queryArgs []interface{},
-) ([]entity.Activity, error) {
-	query := `
-		SELECT id, activity_type, done_at, duration_in_minutes, calories_burned, created_at
-		FROM activities
-		WHERE
-			user_id = $1
-			AND ($2::TEXT IS NULL OR activity_type = $2)
-			AND ($3::TIMESTAMP IS NULL OR done_at >= $3)
-			AND ($4::TIMESTAMP IS NULL OR done_at <= $4)
-			AND ($5::NUMERIC IS NULL OR calories_burned >= $5)
-			AND ($6::NUMERIC IS NULL OR calories_burned <= $6)
-		ORDER BY done_at DESC
-		LIMIT $7 OFFSET $8;
-	`
-	rows, err := r.db.Query(ctx, query, queryArgs...)
+func (r *activityRepository) GetAll(ctx context.Context, filter ActivityFilter) ([]entity.Activity, error) {
+	query, args := activityListQuery(filter)
+	rows, err := r.read.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -57,5 +108,29 @@ func (r *ActivityRepository) GetAll(
 		}
 		activities = append(activities, activity)
 	}
-	return activities, nil
+	return activities, rows.Err()
+}
+
+// CreateBatch relies on the batch running as one implicit transaction,
+// inside WithinTx it joins the transaction of the context instead.
+func (r *activityRepository) CreateBatch(ctx context.Context, userId string, activities []entity.Activity) ([]string, error) {
+	query := `
+		INSERT INTO activities (user_id, activity_type, done_at, duration_in_minutes, calories_burned)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	batch := &pgx.Batch{}
+	for _, activity := range activities {
+		batch.Queue(query, userId, activity.ActivityType, activity.DoneAt, activity.DurationInMinutes, activity.CaloriesBurned)
+	}
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+	ids := make([]string, len(activities))
+	for i := range ids {
+		if err := results.QueryRow().Scan(&ids[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ids, results.Close()
 }
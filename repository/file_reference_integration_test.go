@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TimDebug/FitByte/entity"
+	"github.com/TimDebug/FitByte/testutil/pgtest"
+)
+
+func TestFilterReferencedMatchesWholeKeys(t *testing.T) {
+	db := pgtest.Open(t)
+	ctx := context.Background()
+	id, email, hash := "8d1c3f4e-55c2-4c1b-9d3a-2f1b6c7e9a10", "image@example.com", "hash"
+	imageUri := "https://bucket.s3.region.amazonaws.com/users/a_b.jpg?v=2"
+	user := entity.User{Id: &id, Email: &email, PasswordHash: &hash, ImageUri: &imageUri, Timezone: "UTC"}
+	if _, err := NewSeedRepository(db.Pool).CopyUsers(ctx, []entity.User{user}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Pool.Exec(ctx, `INSERT INTO FileReferences (file_key, owner_table, owner_id) VALUES ('archive/2026-01.csv.gz', $1, 'activities_p2026_01')`, FileOwnerActivityArchive); err != nil {
+		t.Fatal(err)
+	}
+
+	referenced, err := NewFileReferenceRepository(db.Pool).FilterReferenced(ctx, []string{
+		"users/a_b.jpg",          // the image
+		"a_b.jpg",                // only ends the key
+		"s3.region.amazonaws.com/users/a_b.jpg",
+		"users/a%b.jpg",          // would match as a LIKE pattern
+		"users/axb.jpg",          // would match _ as a LIKE pattern
+		"archive/2026-01.csv.gz", // tracked
+		"archive/2026-02.csv.gz",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"users/a_b.jpg": true, "archive/2026-01.csv.gz": true}
+	for key := range referenced {
+		if !want[key] {
+			t.Errorf("%s is reported as referenced", key)
+		}
+	}
+	for key := range want {
+		if _, ok := referenced[key]; !ok {
+			t.Errorf("%s is not reported as referenced", key)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/TimDebug/FitByte/database"
+	"github.com/samber/do/v2"
+)
+
+// FileOwnerActivityArchive owns the archive of a dropped activities
+// partition, the owner id is the partition name.
+const FileOwnerActivityArchive = "activities.archive"
+
+// imageKeyPattern cuts the scheme, host, query and fragment off a URI, like
+// the migration filling Users.image_key.
+var imageKeyPattern = regexp.MustCompile(`^(?:[A-Za-z][A-Za-z0-9+.-]*:)?(?://[^/?#]*)?/?([^?#]*)`)
+
+// ImageKey returns the storage key of an image URI, the path of the URL,
+// to be stored in Users.image_key next to it.
+func ImageKey(uri *string) *string {
+	if uri == nil {
+		return nil
+	}
+	key := imageKeyPattern.FindStringSubmatch(*uri)[1]
+	if key == "" {
+		return nil
+	}
+	return &key
+}
+
+type FileReferenceRepository interface {
+	// FilterReferenced returns the subset of keys that are still
+	// referenced, either through FileReferences or by Users.image_key.
+	FilterReferenced(ctx context.Context, keys []string) (map[string]struct{}, error)
+}
+
+type fileReferenceRepository struct {
+	db database.DBTX
+}
+
+func NewFileReferenceRepository(db database.DBTX) FileReferenceRepository {
+	return &fileReferenceRepository{db: db}
+}
+
+func NewFileReferenceRepositoryInject(i do.Injector) (FileReferenceRepository, error) {
+	db := do.MustInvoke[database.DBTX](i)
+	return NewFileReferenceRepository(db), nil
+}
+
+func (r *fileReferenceRepository) FilterReferenced(
+	ctx context.Context,
+	keys []string,
+) (map[string]struct{}, error) {
+	query := `
+		SELECT k.file_key
+		FROM unnest($1::text[]) AS k(file_key)
+		WHERE EXISTS (
+			SELECT 1 FROM FileReferences r WHERE r.file_key = k.file_key
+		) OR EXISTS (
+			SELECT 1 FROM Users u WHERE u.image_key = k.file_key
+		);
+	`
+	rows, err := r.db.Query(ctx, query, keys)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	referenced := make(map[string]struct{}, len(keys))
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		referenced[key] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return referenced, nil
+}
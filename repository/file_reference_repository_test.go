@@ -0,0 +1,25 @@
+package repository
+
+import "testing"
+
+func TestImageKeyIsThePathOfTheURI(t *testing.T) {
+	for uri, want := range map[string]string{
+		"https://bucket.s3.region.amazonaws.com/users/a_b.jpg": "users/a_b.jpg",
+		"https://cdn.example.com/users/a_b.jpg?v=2#top":        "users/a_b.jpg",
+		"//cdn.example.com/users/a_b.jpg":                      "users/a_b.jpg",
+		"/users/a_b.jpg":                                       "users/a_b.jpg",
+		"users/a_b.jpg":                                        "users/a_b.jpg",
+		"https://cdn.example.com/":                             "",
+	} {
+		got := ""
+		if key := ImageKey(&uri); key != nil {
+			got = *key
+		}
+		if got != want {
+			t.Errorf("ImageKey(%q) = %q, want %q", uri, got, want)
+		}
+	}
+	if ImageKey(nil) != nil {
+		t.Error("ImageKey(nil) is not nil")
+	}
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/TimDebug/FitByte/database"
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/entity"
+	"github.com/TimDebug/FitByte/helper"
+)
+
+var _ database.Transactor = (*Memory)(nil)
+
+// Memory keeps users and activities in process for tests, with the
+// semantics of the Postgres repositories: unique emails (compared case
+// sensitively, like the UNIQUE constraint), the activity filters and the
+// most recent first ordering.
+type Memory struct {
+	mu         sync.Mutex
+	nextId     int
+	users      []entity.User
+	activities []memoryActivity
+}
+
+type memoryActivity struct {
+	userId   string
+	activity entity.Activity
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Users() UserRepository {
+	return memoryUsers{m}
+}
+
+func (m *Memory) Activities() ActivityRepository {
+	return memoryActivities{m}
+}
+
+func (m *Memory) newId() string {
+	m.nextId++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", m.nextId)
+}
+
+// AddActivity stores activity for userId, filling in its id and CreatedAt,
+// and returns the id.
+func (m *Memory) AddActivity(userId string, activity entity.Activity) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.newId()
+	activity.ActivityId = &id
+	if activity.CreatedAt == nil {
+		createdAt := time.Now()
+		activity.CreatedAt = &createdAt
+	}
+	m.activities = append(m.activities, memoryActivity{userId: userId, activity: activity})
+	return id
+}
+
+// WithinTx implements database.Transactor, the changes of a failed fn are
+// undone. Concurrent units of work are not isolated from each other.
+func (m *Memory) WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...database.TxOption) error {
+	m.mu.Lock()
+	users, activities, nextId := slices.Clone(m.users), slices.Clone(m.activities), m.nextId
+	m.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		m.mu.Lock()
+		m.users, m.activities, m.nextId = users, activities, nextId
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+type memoryUsers struct {
+	m *Memory
+}
+
+func (r memoryUsers) find(match func(entity.User) bool) []entity.User {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var users []entity.User
+	for _, user := range r.m.users {
+		if match(user) {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func (r memoryUsers) GetProfile(ctx context.Context, id string) (*entity.User, error) {
+	users := r.find(func(user entity.User) bool { return *user.Id == id })
+	if len(users) == 0 {
+		return nil, helper.ErrNotFound
+	}
+	return &users[0], nil
+}
+
+func (r memoryUsers) GetBatchOfProfiles(ctx context.Context, ids []string) ([]entity.User, error) {
+	return r.find(func(user entity.User) bool { return slices.Contains(ids, *user.Id) }), nil
+}
+
+func (r memoryUsers) VerifyNewUser(ctx context.Context, email string) (bool, error) {
+	if len(r.find(func(user entity.User) bool { return *user.Email == email })) > 0 {
+		return false, helper.ErrConflict
+	}
+	return true, nil
+}
+
+func (r memoryUsers) Login(ctx context.Context, body *dto.UserRequestPayload) ([]entity.User, error) {
+	return r.find(func(user entity.User) bool { return *user.Email == body.Email }), nil
+}
+
+func (r memoryUsers) Register(ctx context.Context, body *entity.User) (string, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, user := range r.m.users {
+		if *user.Email == *body.Email {
+			return "", helper.ErrConflict
+		}
+	}
+
+	user := *body
+	id := r.m.newId()
+	user.Id = &id
+	if user.Timezone == "" {
+		user.Timezone = "UTC" // the column default
+	}
+	r.m.users = append(r.m.users, user)
+	return id, nil
+}
+
+func (r memoryUsers) UpdateTimezone(ctx context.Context, id string, timezone string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for i := range r.m.users {
+		if *r.m.users[i].Id == id {
+			r.m.users[i].Timezone = timezone
+			r.m.users[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return helper.ErrNotFound
+}
+
+type memoryActivities struct {
+	m *Memory
+}
+
+func (r memoryActivities) GetAll(ctx context.Context, filter ActivityFilter) ([]entity.Activity, error) {
+	// Postgres rejects them as well
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, fmt.Errorf("negative limit %d or offset %d", filter.Limit, filter.Offset)
+	}
+	r.m.mu.Lock()
+	var activities []entity.Activity
+	for _, stored := range r.m.activities {
+		if stored.userId == filter.UserId && filter.matches(stored.activity) {
+			activities = append(activities, stored.activity)
+		}
+	}
+	r.m.mu.Unlock()
+
+	slices.SortStableFunc(activities, func(a, b entity.Activity) int {
+		return b.DoneAt.Compare(*a.DoneAt)
+	})
+
+	if filter.Offset >= len(activities) {
+		return nil, nil
+	}
+	activities = activities[filter.Offset:]
+	if filter.Limit < len(activities) {
+		activities = activities[:filter.Limit]
+	}
+	return activities, nil
+}
+
+func (r memoryActivities) CreateBatch(ctx context.Context, userId string, activities []entity.Activity) ([]string, error) {
+	ids := make([]string, len(activities))
+	for i, activity := range activities {
+		ids[i] = r.m.AddActivity(userId, activity)
+	}
+	return ids, nil
+}
+
+func (f ActivityFilter) matches(activity entity.Activity) bool {
+	doneAt := *activity.DoneAt
+	switch {
+	case f.ActivityType != nil && *f.ActivityType != *activity.ActivityType:
+		return false
+	case f.DoneAtFrom != nil && doneAt.Before(*f.DoneAtFrom):
+		return false
+	case f.DoneAtTo != nil && doneAt.After(*f.DoneAtTo):
+		return false
+	case f.CaloriesBurnedMin != nil && *activity.CaloriesBurned < int64(*f.CaloriesBurnedMin):
+		return false
+	case f.CaloriesBurnedMax != nil && *activity.CaloriesBurned > int64(*f.CaloriesBurnedMax):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TimDebug/FitByte/entity"
+	"github.com/TimDebug/FitByte/helper"
+)
+
+func TestMemoryWithinTxUndoesFailedUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemory()
+	email := "jane@example.com"
+
+	errLater := errors.New("later step failed")
+	err := db.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := db.Users().Register(ctx, &entity.User{Email: &email}); err != nil {
+			return err
+		}
+		return errLater
+	})
+	if !errors.Is(err, errLater) {
+		t.Fatalf("WithinTx = %v", err)
+	}
+
+	if _, err := db.Users().VerifyNewUser(ctx, email); err != nil {
+		t.Fatalf("user of the failed unit of work is still there: %v", err)
+	}
+	if _, err := db.Users().Register(ctx, &entity.User{Email: &email}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Users().Register(ctx, &entity.User{Email: &email}); !errors.Is(err, helper.ErrConflict) {
+		t.Fatalf("duplicate Register = %v, want %v", err, helper.ErrConflict)
+	}
+}
+
+func TestMemoryGetAllRejectsNegativePaging(t *testing.T) {
+	db := NewMemory()
+	for _, filter := range []ActivityFilter{{UserId: "jane", Limit: -1}, {UserId: "jane", Limit: 5, Offset: -1}} {
+		if _, err := db.Activities().GetAll(context.Background(), filter); err == nil {
+			t.Errorf("GetAll(limit %d, offset %d) succeeded", filter.Limit, filter.Offset)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/TimDebug/FitByte/database"
+	"github.com/TimDebug/FitByte/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/samber/do/v2"
+)
+
+// SeedRepository bulk loads generated data with COPY, skipping the
+// per-row round trips of the regular repositories.
+type SeedRepository interface {
+	// CopyUsers inserts users with their ids and returns the number of rows.
+	CopyUsers(ctx context.Context, users []entity.User) (int64, error)
+	// CopyActivities inserts activities, each with its UserId set, and
+	// returns the number of rows.
+	CopyActivities(ctx context.Context, activities []entity.Activity) (int64, error)
+}
+
+type seedRepository struct {
+	db database.DBTX
+}
+
+func NewSeedRepository(db database.DBTX) SeedRepository {
+	return &seedRepository{db: db}
+}
+
+func NewSeedRepositoryInject(i do.Injector) (SeedRepository, error) {
+	db := do.MustInvoke[database.DBTX](i)
+	return NewSeedRepository(db), nil
+}
+
+func (r *seedRepository) CopyUsers(ctx context.Context, users []entity.User) (int64, error) {
+	columns := []string{
+		"id", "email", "password_hash", "preference", "weight_unit", "height_unit",
+		"weight", "height", "name", "image_uri", "image_key", "timezone", "created_at", "updated_at",
+	}
+	return r.db.CopyFrom(ctx, pgx.Identifier{"users"}, columns, pgx.CopyFromSlice(len(users), func(i int) ([]any, error) {
+		u := users[i]
+		return []any{
+			u.Id, u.Email, u.PasswordHash, u.Preference, u.WeightUnit, u.HeightUnit,
+			u.Weight, u.Height, u.Name, u.ImageUri, ImageKey(u.ImageUri), u.Timezone, u.CreatedAt, u.UpdatedAt,
+		}, nil
+	}))
+}
+
+func (r *seedRepository) CopyActivities(ctx context.Context, activities []entity.Activity) (int64, error) {
+	columns := []string{
+		"user_id", "activity_type", "done_at", "duration_in_minutes", "calories_burned", "created_at", "updated_at",
+	}
+	return r.db.CopyFrom(ctx, pgx.Identifier{"activities"}, columns, pgx.CopyFromSlice(len(activities), func(i int) ([]any, error) {
+		a := activities[i]
+		return []any{
+			a.UserId, a.ActivityType, a.DoneAt, a.DurationInMinutes, a.CaloriesBurned, a.CreatedAt, a.CreatedAt,
+		}, nil
+	}))
+}
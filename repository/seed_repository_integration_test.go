@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/entity"
+	"github.com/TimDebug/FitByte/testutil/pgtest"
+)
+
+func TestSeedCopiesUsersAndActivities(t *testing.T) {
+	db := pgtest.Open(t)
+	repo := NewSeedRepository(db.Pool)
+	ctx := context.Background()
+
+	id, email, hash, preference, weightUnit, heightUnit := "seed-user", "seed@example.com", "hash", "CARDIO", "KG", "CM"
+	weight, height := 70, 175
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	copied, err := repo.CopyUsers(ctx, []entity.User{{
+		Id: &id, Email: &email, PasswordHash: &hash,
+		Preference: &preference, WeightUnit: &weightUnit, HeightUnit: &heightUnit,
+		Weight: &weight, Height: &height, Timezone: "Asia/Jakarta",
+		CreatedAt: createdAt, UpdatedAt: createdAt,
+	}})
+	if err != nil || copied != 1 {
+		t.Fatalf("CopyUsers = %d, %v, want 1 row", copied, err)
+	}
+
+	activityType := "Running"
+	duration, calories := int64(30), int64(300)
+	activities := make([]entity.Activity, 3)
+	for i := range activities {
+		doneAt := createdAt.AddDate(0, 0, i)
+		activities[i] = entity.Activity{
+			UserId: &id, ActivityType: &activityType, DoneAt: &doneAt,
+			DurationInMinutes: &duration, CaloriesBurned: &calories, CreatedAt: &doneAt,
+		}
+	}
+	copied, err = repo.CopyActivities(ctx, activities)
+	if err != nil || copied != 3 {
+		t.Fatalf("CopyActivities = %d, %v, want 3 rows", copied, err)
+	}
+
+	got, err := NewActivityRepository(db.Pool, db.Pool).GetAll(ctx, ActivityFilter{UserId: id, Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || !got[0].DoneAt.Equal(*activities[2].DoneAt) {
+		t.Fatalf("activities = %d, want 3, most recent first", len(got))
+	}
+}
@@ -2,39 +2,62 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
 
+	"github.com/TimDebug/FitByte/database"
 	"github.com/TimDebug/FitByte/dto"
 	"github.com/TimDebug/FitByte/entity"
 	"github.com/TimDebug/FitByte/helper"
-	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/samber/do/v2"
 )
 
-type UserRepository struct {
-	db *pgxpool.Pool
+type UserRepository interface {
+	// GetProfile returns helper.ErrNotFound for an unknown id.
+	GetProfile(ctx context.Context, id string) (*entity.User, error)
+	GetBatchOfProfiles(ctx context.Context, ids []string) ([]entity.User, error)
+	// VerifyNewUser returns helper.ErrConflict when email is taken.
+	VerifyNewUser(ctx context.Context, email string) (bool, error)
+	// Login returns the users registered with body.Email, at most one.
+	Login(ctx context.Context, body *dto.UserRequestPayload) ([]entity.User, error)
+	// Register returns helper.ErrConflict when the email is taken.
+	Register(ctx context.Context, body *entity.User) (userId string, err error)
+	// UpdateTimezone returns helper.ErrNotFound for an unknown id.
+	UpdateTimezone(ctx context.Context, id string, timezone string) error
 }
 
-func NewUserRepository(db *pgxpool.Pool) UserRepository {
-	return UserRepository{db: db}
+// userRepository reads profiles through read, they may lag a replica
+// behind. Credentials and uniqueness checks always query the primary.
+type userRepository struct {
+	db   database.DBTX
+	read database.ReadDBTX
+}
+
+func NewUserRepository(db database.DBTX, read database.ReadDBTX) UserRepository {
+	return &userRepository{db: db, read: read}
 }
 
 func NewUserRepositoryInject(i do.Injector) (UserRepository, error) {
-	db := do.MustInvoke[*pgxpool.Pool](i)
-	return NewUserRepository(db), nil
+	db := do.MustInvoke[database.DBTX](i)
+	read := do.MustInvoke[database.ReadDBTX](i)
+	return NewUserRepository(db, read), nil
 }
 
-func (r *UserRepository) GetProfile(ctx context.Context, id string) (*entity.User, error) {
-	row := r.db.QueryRow(
+func (r *userRepository) GetProfile(ctx context.Context, id string) (*entity.User, error) {
+	row := r.read.QueryRow(
 		ctx,
-		`SELECT email, name, image_uri FROM Users WHERE id = $1`,
+		`SELECT email, name, image_uri, timezone FROM Users WHERE id = $1`,
 		id,
 	)
 
 	var user entity.User
-	err := row.Scan(&user.Email, &user.Name, &user.ImageUri)
+	err := row.Scan(&user.Email, &user.Name, &user.ImageUri, &user.Timezone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, helper.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +65,7 @@ func (r *UserRepository) GetProfile(ctx context.Context, id string) (*entity.Use
 	return &user, nil
 }
 
-func (r *UserRepository) GetBatchOfProfiles(
+func (r *userRepository) GetBatchOfProfiles(
 	ctx context.Context,
 	ids []string,
 ) ([]entity.User, error) {
@@ -51,7 +74,7 @@ func (r *UserRepository) GetBatchOfProfiles(
 		FROM Users
 		WHERE id = ANY($1::text[]);
 	`
-	rows, err := r.db.Query(ctx, query, ids)
+	rows, err := r.read.Query(ctx, query, ids)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err) // Return wrapped errors
 	}
@@ -70,7 +93,7 @@ func (r *UserRepository) GetBatchOfProfiles(
 	return users, nil
 }
 
-func (r *UserRepository) VerifyNewUser(ctx *gin.Context, email string) (bool, error) {
+func (r *userRepository) VerifyNewUser(ctx context.Context, email string) (bool, error) {
 	query := `
 		SELECT EXISTS (
 			SELECT 1
@@ -90,7 +113,7 @@ func (r *UserRepository) VerifyNewUser(ctx *gin.Context, email string) (bool, er
 	return true, nil
 }
 
-func (r *UserRepository) Login(ctx *gin.Context, body *dto.UserRequestPayload) ([]entity.User, error) {
+func (r *userRepository) Login(ctx context.Context, body *dto.UserRequestPayload) ([]entity.User, error) {
 	query := `
 		SELECT id, email, password_hash
 		FROM Users
@@ -118,17 +141,37 @@ func (r *UserRepository) Login(ctx *gin.Context, body *dto.UserRequestPayload) (
 	return users, nil
 }
 
-func (r *UserRepository) Register(ctx *gin.Context, body *entity.User) (userId string, err error) {
+func (r *userRepository) Register(ctx context.Context, body *entity.User) (userId string, err error) {
 	query := `
 		INSERT INTO Users (email, password_hash, created_at, updated_at)
 		VALUES ($1, $2, $3, $4)
 		RETURNING Users.id
 	`
-	createdAtUnix := time.Unix(body.CreatedAt, 0)
-	row := r.db.QueryRow(ctx, query, body.Email, body.PasswordHash, createdAtUnix, createdAtUnix)
+	row := r.db.QueryRow(ctx, query, body.Email, body.PasswordHash, body.CreatedAt, body.UpdatedAt)
 	err = row.Scan(&userId)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return "", helper.ErrConflict
+	}
 	if err != nil {
 		return "", err
 	}
-	return userId, err
+	// The token is used right away, read the account from the primary until
+	// the replicas have it
+	database.MarkWritten(ctx, r.db, userId)
+	return userId, nil
+}
+
+func (r *userRepository) UpdateTimezone(ctx context.Context, id string, timezone string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE Users SET timezone = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, timezone)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.ErrNotFound
+	}
+	return nil
 }
@@ -0,0 +1,103 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// Value holds a resolved secret. Every way of printing or encoding it yields
+// "[REDACTED]", call Reveal to get the actual value.
+type Value string
+
+func (v Value) Reveal() string { return string(v) }
+
+func (v Value) String() string                    { return redacted }
+func (v Value) GoString() string                  { return redacted }
+func (v Value) MarshalText() ([]byte, error)      { return []byte(redacted), nil }
+func (v Value) MarshalJSON() ([]byte, error)      { return []byte(`"` + redacted + `"`), nil }
+func (v Value) MarshalYAML() (interface{}, error) { return redacted, nil }
+
+// Provider resolves the part of a reference that follows "<scheme>:".
+type Provider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+type ProviderFunc func(ctx context.Context, ref string) (string, error)
+
+func (f ProviderFunc) Resolve(ctx context.Context, ref string) (string, error) { return f(ctx, ref) }
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"env":  ProviderFunc(resolveEnv),
+		"file": ProviderFunc(resolveFile),
+	}
+)
+
+// RegisterProvider makes refs starting with "<scheme>:" resolve through p.
+// The scheme "literal" is reserved.
+func RegisterProvider(scheme string, p Provider) {
+	if scheme == literalScheme {
+		panic("secret: the literal scheme cannot have a provider")
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = p
+}
+
+// literalScheme marks a plain value that would otherwise read as a
+// reference, "literal:env:x" is the value "env:x".
+const literalScheme = "literal"
+
+// Resolve turns a reference such as "env:NAME", "file:///run/secrets/jwt" or
+// "vault:secret/data/app#key" into its value. A string without a registered
+// scheme is returned as is, so plain values keep working. A plain value that
+// starts with a registered scheme and a colon has to be written as
+// "literal:" + value.
+func Resolve(ctx context.Context, raw string) (string, error) {
+	scheme, ref, found := strings.Cut(raw, ":")
+	if !found {
+		return raw, nil
+	}
+	if scheme == literalScheme {
+		return ref, nil
+	}
+
+	providersMu.RLock()
+	provider, ok := providers[scheme]
+	providersMu.RUnlock()
+	if !ok {
+		return raw, nil
+	}
+
+	value, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		// Only the scheme is reported, the reference may be sensitive too
+		return "", fmt.Errorf("resolve %s secret: %w", scheme, err)
+	}
+	return value, nil
+}
+
+func resolveEnv(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// resolveFile reads file:///path or file:path, e.g. a Docker Swarm secret
+// mounted under /run/secrets. The trailing newline is dropped.
+func resolveFile(ctx context.Context, ref string) (string, error) {
+	path := strings.TrimPrefix(ref, "//")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveEnvAndFile(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("FITBYTE_TEST_SECRET", "from-env")
+
+	path := filepath.Join(t.TempDir(), "jwt")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+
+	cases := map[string]string{
+		"env:FITBYTE_TEST_SECRET": "from-env",
+		"file://" + path:          "from-file",
+		"plain-value":             "plain-value",
+		"unknown:scheme":          "unknown:scheme",
+		"literal:env:not-a-ref":   "env:not-a-ref",
+		"literal:literal:x":       "literal:x",
+	}
+	for ref, want := range cases {
+		got, err := Resolve(ctx, ref)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+
+	if _, err := Resolve(ctx, "env:FITBYTE_TEST_MISSING"); err == nil {
+		t.Errorf("Resolve of a missing env var must fail")
+	}
+	if _, err := Resolve(ctx, "file:///does/not/exist"); err == nil {
+		t.Errorf("Resolve of a missing file must fail")
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/fitbyte":
+			fmt.Fprint(w, `{"data":{"data":{"jwt":"kv2-secret"},"metadata":{"version":3}}}`)
+		case "/v1/kv/fitbyte":
+			fmt.Fprint(w, `{"data":{"jwt":"kv1-secret"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer stub.Close()
+
+	ctx := context.Background()
+	RegisterProvider("vault", NewVaultProvider(stub.URL, "root-token"))
+
+	for ref, want := range map[string]string{
+		"vault:secret/data/fitbyte#jwt": "kv2-secret",
+		"vault:kv/fitbyte#jwt":          "kv1-secret",
+	} {
+		got, err := Resolve(ctx, ref)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+
+	for _, ref := range []string{"vault:secret/data/fitbyte#missing", "vault:secret/data/other#jwt", "vault:secret/data/fitbyte"} {
+		if _, err := Resolve(ctx, ref); err == nil {
+			t.Errorf("Resolve(%q) must fail", ref)
+		}
+	}
+
+	RegisterProvider("vault", NewVaultProvider(stub.URL, "wrong-token"))
+	if _, err := Resolve(ctx, "vault:secret/data/fitbyte#jwt"); err == nil {
+		t.Errorf("Resolve with a wrong token must fail")
+	}
+}
+
+func TestValueIsRedacted(t *testing.T) {
+	value := Value("hunter2")
+
+	outputs := []string{
+		fmt.Sprint(value),
+		fmt.Sprintf("%v %s %q %+v %#v", value, value, value, value, value),
+		fmt.Sprintf("%+v", struct{ Password Value }{value}),
+	}
+	data, err := json.Marshal(map[string]Value{"password": value})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	outputs = append(outputs, string(data))
+
+	for _, output := range outputs {
+		if strings.Contains(output, "hunter2") {
+			t.Errorf("secret leaked in %q", output)
+		}
+	}
+	if value.Reveal() != "hunter2" {
+		t.Errorf("Reveal() = %q", value.Reveal())
+	}
+}
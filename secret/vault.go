@@ -0,0 +1,78 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads secrets from a Vault-compatible HTTP API. References
+// look like "secret/data/fitbyte#jwt", the part after # names the field.
+// Both KV v2 ({"data":{"data":{...}}}) and KV v1 ({"data":{...}}) responses
+// are understood.
+type VaultProvider struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+func NewVaultProvider(addr, token string) *VaultProvider {
+	return &VaultProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (v *VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, found := strings.Cut(ref, "#")
+	if !found || field == "" {
+		return "", errors.New(`vault reference must look like "path#field"`)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s for %s", res.Status, path)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+
+	fields := body.Data
+	if nested, ok := body.Data["data"]; ok {
+		// KV v2 wraps the fields once more
+		var v2 map[string]json.RawMessage
+		if err := json.Unmarshal(nested, &v2); err == nil {
+			fields = v2
+		}
+	}
+
+	raw, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found in %s", field, path)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("field %q in %s is not a string", field, path)
+	}
+	return value, nil
+}
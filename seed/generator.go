@@ -0,0 +1,300 @@
+// Package seed generates plausible users and activities for demos and load
+// tests. The same Options always produce the same data.
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TimDebug/FitByte/entity"
+	"github.com/TimDebug/FitByte/handler"
+)
+
+// DefaultMix favours the everyday activities.
+var DefaultMix = map[handler.ActivityType]int{
+	handler.Walking:    5,
+	handler.Running:    3,
+	handler.Cycling:    3,
+	handler.Yoga:       2,
+	handler.Stretching: 2,
+	handler.Swimming:   1,
+	handler.Dancing:    1,
+	handler.Hiking:     1,
+	handler.HIIT:       1,
+	handler.JumpRope:   1,
+}
+
+var (
+	firstNames = []string{"Adi", "Budi", "Citra", "Dewi", "Eka", "Fajar", "Gita", "Hana", "Indra", "Joko", "Kartika", "Lina", "Maya", "Nanda", "Putri", "Rizky", "Sari", "Tono", "Wulan", "Yusuf"}
+	lastNames  = []string{"Pratama", "Saputra", "Wijaya", "Lestari", "Hidayat", "Kusuma", "Santoso", "Siregar", "Nugroho", "Halim"}
+	timezones  = []string{"Asia/Jakarta", "Asia/Jakarta", "Asia/Jakarta", "Asia/Makassar", "Asia/Jayapura", "Asia/Singapore", "Europe/Amsterdam", "UTC"}
+)
+
+// durations are the usual session lengths in minutes of each activity.
+var durations = map[handler.ActivityType][2]int{
+	handler.Walking:    {20, 60},
+	handler.Yoga:       {20, 75},
+	handler.Stretching: {10, 30},
+	handler.Cycling:    {30, 120},
+	handler.Swimming:   {20, 60},
+	handler.Dancing:    {30, 90},
+	handler.Hiking:     {60, 240},
+	handler.Running:    {20, 75},
+	handler.HIIT:       {15, 40},
+	handler.JumpRope:   {10, 30},
+}
+
+type Options struct {
+	// Seed makes the data reproducible, each seed yields other users.
+	Seed   uint64
+	Users  int
+	Months int
+	// Mix weighs the activity types, users pick their favourites from it.
+	Mix map[handler.ActivityType]int
+	// DaysPerWeek is the mean number of active days of a user.
+	DaysPerWeek float64
+	// Streak is the mean number of consecutive active days.
+	Streak float64
+	// PasswordHash is shared by every user, hashing one per user would
+	// dominate the run time.
+	PasswordHash string
+	// Now ends the generated history. DefaultOptions takes the start of the
+	// current day in UTC, so runs on the same day generate the same data.
+	Now time.Time
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Seed:        1,
+		Users:       100,
+		Months:      6,
+		Mix:         DefaultMix,
+		DaysPerWeek: 3.5,
+		Streak:      3,
+		Now:         time.Now().UTC().Truncate(24 * time.Hour),
+	}
+}
+
+func (o Options) Validate() error {
+	if o.Users < 1 {
+		return fmt.Errorf("seed: users must be at least 1, got %d", o.Users)
+	}
+	if o.Months < 1 {
+		return fmt.Errorf("seed: months must be at least 1, got %d", o.Months)
+	}
+	if o.DaysPerWeek <= 0 || o.DaysPerWeek > 7 {
+		return fmt.Errorf("seed: days per week must be in (0, 7], got %g", o.DaysPerWeek)
+	}
+	if o.Streak < 1 {
+		return fmt.Errorf("seed: streak must be at least 1, got %g", o.Streak)
+	}
+	total := 0
+	for activityType, weight := range o.Mix {
+		if !handler.IsValidActivityType(activityType) {
+			return fmt.Errorf("seed: unknown activity type %q", activityType)
+		}
+		if weight < 0 {
+			return fmt.Errorf("seed: negative weight for %s", activityType)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("seed: the activity mix is empty")
+	}
+	return nil
+}
+
+// ParseMix reads weights such as "Walking=3,Running=1".
+func ParseMix(s string) (map[handler.ActivityType]int, error) {
+	mix := make(map[handler.ActivityType]int)
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("seed: mix entry %q is not type=weight", part)
+		}
+		weight, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("seed: weight of %s: %w", name, err)
+		}
+		activityType := handler.ActivityType(name)
+		if !handler.IsValidActivityType(activityType) {
+			return nil, fmt.Errorf("seed: unknown activity type %q", name)
+		}
+		mix[activityType] = weight
+	}
+	return mix, nil
+}
+
+type Generator struct {
+	opts  Options
+	types []handler.ActivityType
+	start time.Time
+}
+
+func NewGenerator(opts Options) (*Generator, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	types := make([]handler.ActivityType, 0, len(opts.Mix))
+	for activityType := range opts.Mix {
+		types = append(types, activityType)
+	}
+	// map order is random, the draws must not be
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	return &Generator{
+		opts:  opts,
+		types: types,
+		start: opts.Now.AddDate(0, -opts.Months, 0),
+	}, nil
+}
+
+// rand gives user n a stream of its own, so a user does not change when
+// the number of users or the order of generation does.
+func (g *Generator) rand(n int, stream uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(g.opts.Seed, uint64(n)<<8|stream))
+}
+
+const (
+	userStream uint64 = iota
+	activityStream
+)
+
+// User returns user n, 0 <= n < Options.Users.
+func (g *Generator) User(n int) entity.User {
+	r := g.rand(n, userStream)
+
+	id := uuid(r)
+	email := fmt.Sprintf("seed%d.user%d@example.com", g.opts.Seed, n)
+	name := pick(r, firstNames) + " " + pick(r, lastNames)
+	preference := pick(r, []string{"CARDIO", "WEIGHT"})
+	weightUnit := pick(r, []string{"KG", "KG", "LBS"})
+	heightUnit := pick(r, []string{"CM", "CM", "INCH"})
+
+	weight := 45 + r.IntN(65)
+	height := 150 + r.IntN(45)
+	if weightUnit == "LBS" {
+		weight = int(math.Round(float64(weight) * 2.20462))
+	}
+	if heightUnit == "INCH" {
+		height = int(math.Round(float64(height) / 2.54))
+	}
+
+	// Accounts predate the history by up to a month
+	createdAt := g.start.Add(-time.Duration(r.Int64N(int64(30 * 24 * time.Hour)))).Truncate(time.Second)
+	return entity.User{
+		Id:           &id,
+		Email:        &email,
+		PasswordHash: &g.opts.PasswordHash,
+		Preference:   &preference,
+		WeightUnit:   &weightUnit,
+		HeightUnit:   &heightUnit,
+		Weight:       &weight,
+		Height:       &height,
+		Name:         &name,
+		Timezone:     pick(r, timezones),
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+}
+
+// Activities returns the history of user, which must come from User.
+//
+// Active and rest days follow a two state Markov chain: an active day is
+// followed by another with a probability giving the mean Streak, a rest
+// day by an active one with the probability that makes the user active on
+// DaysPerWeek days in the long run.
+func (g *Generator) Activities(n int, user entity.User) []entity.Activity {
+	r := g.rand(n, activityStream)
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	// Users differ in how active they are and what they like doing
+	active := math.Min(g.opts.DaysPerWeek*(0.6+0.8*r.Float64())/7, 0.95)
+	stay := 1 - 1/g.opts.Streak
+	resume := math.Min(active*(1-stay)/(1-active), 1)
+	weights := make([]float64, len(g.types))
+	for i, activityType := range g.types {
+		weights[i] = float64(g.opts.Mix[activityType]) * (0.2 + 1.6*r.Float64())
+	}
+	hour := 6 + r.IntN(14)
+
+	var activities []entity.Activity
+	day := time.Date(g.start.Year(), g.start.Month(), g.start.Day(), 0, 0, 0, 0, loc)
+	isActive := r.Float64() < active
+	for ; day.Before(g.opts.Now); day = day.AddDate(0, 0, 1) {
+		if isActive {
+			sessions := 1
+			if r.Float64() < 0.1 {
+				sessions = 2
+			}
+			for s := 0; s < sessions; s++ {
+				doneAt := day.Add(time.Duration(hour+3*s)*time.Hour + time.Duration(r.IntN(90)-45)*time.Minute)
+				if doneAt.Before(g.start) || doneAt.After(g.opts.Now) {
+					continue
+				}
+				activities = append(activities, g.activity(r, user, weights, doneAt))
+			}
+			isActive = r.Float64() < stay
+		} else {
+			isActive = r.Float64() < resume
+		}
+	}
+	return activities
+}
+
+func (g *Generator) activity(r *rand.Rand, user entity.User, weights []float64, doneAt time.Time) entity.Activity {
+	activityType := g.types[weighted(r, weights)]
+	bounds := durations[activityType]
+	minutes := bounds[0] + r.IntN(bounds[1]-bounds[0]+1)
+	burned, _ := handler.CaloriesBurned(activityType, minutes)
+	duration, calories := int64(minutes), int64(burned)
+
+	typeName := string(activityType)
+	doneAt = doneAt.UTC().Truncate(time.Second)
+	// Logged shortly after finishing
+	createdAt := doneAt.Add(time.Duration(duration)*time.Minute + time.Duration(r.IntN(60))*time.Minute)
+	return entity.Activity{
+		UserId:            user.Id,
+		ActivityType:      &typeName,
+		DoneAt:            &doneAt,
+		DurationInMinutes: &duration,
+		CaloriesBurned:    &calories,
+		CreatedAt:         &createdAt,
+	}
+}
+
+func pick[T any](r *rand.Rand, values []T) T {
+	return values[r.IntN(len(values))]
+}
+
+func weighted(r *rand.Rand, weights []float64) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	x := r.Float64() * total
+	for i, w := range weights {
+		if x < w {
+			return i
+		}
+		x -= w
+	}
+	return len(weights) - 1
+}
+
+// uuid formats 16 random bytes as a version 4 UUID.
+func uuid(r *rand.Rand) string {
+	hi, lo := r.Uint64(), r.Uint64()
+	hi = hi&^(0xf<<12) | 0x4<<12
+	lo = lo&^(0x3<<62) | 0x2<<62
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", hi>>32, hi>>16&0xffff, hi&0xffff, lo>>48, lo&0xffffffffffff)
+}
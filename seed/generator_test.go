@@ -0,0 +1,127 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/handler"
+)
+
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.Users = 20
+	opts.PasswordHash = "hash"
+	opts.Now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	return opts
+}
+
+func generate(t *testing.T, opts Options, n int) []any {
+	t.Helper()
+	g, err := NewGenerator(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := g.User(n)
+	return []any{user, g.Activities(n, user)}
+}
+
+func TestDefaultOptionsEndAtTheStartOfTheDay(t *testing.T) {
+	now := DefaultOptions().Now
+	if now.Location() != time.UTC || !now.Equal(now.Truncate(24*time.Hour)) {
+		t.Fatalf("Now = %s, want midnight UTC", now)
+	}
+}
+
+func TestGeneratorIsDeterministic(t *testing.T) {
+	opts := testOptions()
+	if !reflect.DeepEqual(generate(t, opts, 3), generate(t, opts, 3)) {
+		t.Fatal("the same options generated different data")
+	}
+
+	// A user does not depend on how many others are generated
+	more := opts
+	more.Users = 1000
+	if !reflect.DeepEqual(generate(t, opts, 3), generate(t, more, 3)) {
+		t.Fatal("user 3 changed with the number of users")
+	}
+
+	other := opts
+	other.Seed = 2
+	if reflect.DeepEqual(generate(t, opts, 3), generate(t, other, 3)) {
+		t.Fatal("another seed generated the same data")
+	}
+}
+
+func TestGeneratorActivities(t *testing.T) {
+	opts := testOptions()
+	opts.Mix = map[handler.ActivityType]int{handler.Walking: 1, handler.Running: 1, handler.Yoga: 0}
+	g, err := NewGenerator(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := opts.Now.AddDate(0, -opts.Months, 0)
+
+	total := 0
+	for n := 0; n < opts.Users; n++ {
+		user := g.User(n)
+		activities := g.Activities(n, user)
+		total += len(activities)
+		for _, activity := range activities {
+			if *activity.UserId != *user.Id {
+				t.Fatalf("activity of user %s, want %s", *activity.UserId, *user.Id)
+			}
+			activityType := handler.ActivityType(*activity.ActivityType)
+			if activityType != handler.Walking && activityType != handler.Running {
+				t.Fatalf("activity type %s is not in the mix", activityType)
+			}
+			if activity.DoneAt.Before(start) || activity.DoneAt.After(opts.Now) {
+				t.Fatalf("done at %s outside %s - %s", activity.DoneAt, start, opts.Now)
+			}
+			perMinute, _ := handler.GetCaloriesPerMinute(activityType)
+			if want := int64(float64(*activity.DurationInMinutes) * perMinute); *activity.CaloriesBurned != want {
+				t.Fatalf("%s of %d minutes burned %d calories, want %d", activityType, *activity.DurationInMinutes, *activity.CaloriesBurned, want)
+			}
+		}
+	}
+
+	// 3.5 days a week over six months is about 90 activities per user
+	perUser := float64(total) / float64(opts.Users)
+	if perUser < 50 || perUser > 140 {
+		t.Fatalf("%.1f activities per user, want about 90", perUser)
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("Walking=3, Running=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[handler.ActivityType]int{handler.Walking: 3, handler.Running: 1}; !reflect.DeepEqual(mix, want) {
+		t.Fatalf("mix = %v, want %v", mix, want)
+	}
+
+	for _, s := range []string{"Walking", "Walking=many", "Sleeping=1"} {
+		if _, err := ParseMix(s); err == nil {
+			t.Errorf("ParseMix(%q) succeeded", s)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	for name, change := range map[string]func(*Options){
+		"no users":        func(o *Options) { o.Users = 0 },
+		"no months":       func(o *Options) { o.Months = 0 },
+		"eight days":      func(o *Options) { o.DaysPerWeek = 8 },
+		"short streak":    func(o *Options) { o.Streak = 0.5 },
+		"empty mix":       func(o *Options) { o.Mix = map[handler.ActivityType]int{handler.Walking: 0} },
+		"unknown type":    func(o *Options) { o.Mix = map[handler.ActivityType]int{"Sleeping": 1} },
+		"negative weight": func(o *Options) { o.Mix = map[handler.ActivityType]int{handler.Walking: 1, handler.Running: -1} },
+	} {
+		opts := testOptions()
+		change(&opts)
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%s: Validate succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/database"
+	"github.com/TimDebug/FitByte/domain"
+	"github.com/TimDebug/FitByte/health"
+	"github.com/TimDebug/FitByte/infrastructure/migration"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do/v2"
+)
+
+// readinessChecker lists the dependencies a replica needs to serve traffic.
+func readinessChecker(i do.Injector, timeout time.Duration) *health.Checker {
+	return health.NewChecker(timeout,
+		health.Service[*database.DB]("postgres", i),
+		health.Service[domain.StorageClient]("storage", i),
+		health.Service[cache.Store]("cache", i),
+		health.Service[*migration.Status]("migrations", i),
+	)
+}
+
+func (s *Server) registerHealthRoutes(r *gin.Engine, readiness *health.Checker) {
+	// Liveness only proves the process serves HTTP, restarting it does not
+	// fix a database outage
+	r.GET("/healthz", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+	})
+
+	r.GET("/readyz", func(ctx *gin.Context) {
+		if !s.Ready() {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusDown, "error": "shutting down"})
+			return
+		}
+
+		report := readiness.Run(ctx)
+		status := http.StatusOK
+		if !report.Healthy() {
+			status = http.StatusServiceUnavailable
+		}
+		ctx.JSON(status, report)
+	})
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"errors"
+	"expvar"
+	"log"
+	"net/http"
+
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/database"
+	"github.com/TimDebug/FitByte/logger"
+	"github.com/TimDebug/FitByte/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/do/v2"
+)
+
+// registerCollectors adds the collectors that read live state of the
+// dependencies on every scrape.
+func registerCollectors(i do.Injector) {
+	collectors := []prometheus.Collector{
+		metrics.NewPoolCollector(do.MustInvoke[*database.DB](i).Pool),
+	}
+	if store, ok := cache.Unwrap(do.MustInvoke[cache.Store](i)).(*cache.MemoryStore); ok {
+		collectors = append(collectors, metrics.NewRistrettoCollector(store.Metrics()))
+	}
+
+	for _, collector := range collectors {
+		err := metrics.Registry.Register(collector)
+		if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			log.Printf("Failed to register metrics collector: %v", err)
+		}
+	}
+}
+
+// newAdminServer serves operator endpoints that must not be reachable on
+// the API port, such as changing log levels.
+func newAdminServer(addr string, withMetrics bool, logHandler *logger.LogHandler) *http.Server {
+	mux := http.NewServeMux()
+	if withMetrics {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+	// Cache hit/miss counters are published under the "cache" key
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/admin/log/levels/", http.StripPrefix("/admin/log/levels", logHandler.LevelHandler()))
+	return &http.Server{Addr: addr, Handler: mux}
+}
@@ -3,12 +3,13 @@ package server
 import (
 	"net/http"
 
-	"github.com/TimDebug/FitByte/di"
+	"github.com/TimDebug/FitByte/auth"
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/config"
 	"github.com/TimDebug/FitByte/handler"
 	"github.com/TimDebug/FitByte/helper"
 	"github.com/TimDebug/FitByte/middleware"
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/samber/do/v2"
 
 	_ "github.com/TimDebug/FitByte/docs"
@@ -16,7 +17,8 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func NewRouter(r *gin.Engine, db *pgxpool.Pool) {
+// NewRouter registers the API routes, their handlers come from i.
+func NewRouter(r *gin.Engine, i do.Injector) {
 	r.NoRoute(func(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, helper.ErrNotFound)
 	})
@@ -27,9 +29,17 @@ func NewRouter(r *gin.Engine, db *pgxpool.Pool) {
 		swaggerRoute.GET("swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
-	userHandler := do.MustInvoke[handler.UserHandler](di.Injector)
-	authHandler := do.MustInvoke[handler.AuthorizationHandler](di.Injector)
-	activityHandler := do.MustInvoke[handler.ActivityHandler](di.Injector)
+	userHandler := do.MustInvoke[handler.UserHandler](i)
+	authHandler := do.MustInvoke[handler.AuthorizationHandler](i)
+	activityHandler := do.MustInvoke[handler.ActivityHandler](i)
+
+	cfg := do.MustInvoke[*config.Config](i)
+	authorization := middleware.Authorization(do.MustInvoke[auth.Service](i))
+	httpCache := middleware.NewHTTPCache(
+		do.MustInvoke[cache.Store](i),
+		cfg.Cache.HttpStoreResponses,
+		cfg.Cache.HttpResponseTtl,
+	)
 
 	controllers := r.Group("/v1")
 	{
@@ -37,11 +47,13 @@ func NewRouter(r *gin.Engine, db *pgxpool.Pool) {
 		controllers.POST("/register", authHandler.Register)
 		user := controllers.Group("/user")
 		{
-			user.GET("", middleware.Authorization, userHandler.Get)
+			user.GET("", authorization, httpCache.Handle, userHandler.Get)
+			user.PUT("/timezone", authorization, userHandler.UpdateTimezone)
 		}
 		activity := controllers.Group("/activity")
 		{
-			activity.GET("", middleware.Authorization, activityHandler.GetAll)
+			activity.GET("", authorization, httpCache.Handle, activityHandler.GetAll)
+			activity.POST("/batch", authorization, activityHandler.CreateBatch)
 		}
 	}
 }
@@ -0,0 +1,327 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/database"
+	"github.com/TimDebug/FitByte/di"
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/logger"
+	"github.com/TimDebug/FitByte/testutil/pgtest"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do/v2"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m))
+}
+
+// newTestRouter serves the real routes and handlers on the schema of db.
+func newTestRouter(t *testing.T, db *pgtest.DB) http.Handler {
+	t.Helper()
+	t.Setenv("JWT_SECRET_KEY", "integration-secret")
+	cfg, err := config.LoadFile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := di.New()
+	do.OverrideValue(i, cfg)
+	do.OverrideValue(i, &database.DB{Pool: db.Pool})
+	do.OverrideValue(i, *logger.NewWriterLogHandler(io.Discard))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.ContextWithFallback = true
+	NewRouter(r, i)
+	return r
+}
+
+func serve(t *testing.T, h http.Handler, method, target, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func login(t *testing.T, h http.Handler, user pgtest.User) string {
+	t.Helper()
+	rec := serve(t, h, http.MethodPost, "/v1/login", "", dto.UserRequestPayload{Email: user.Email, Password: user.Password})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: status %d, body %s", rec.Code, rec.Body)
+	}
+	var response dto.ResponseAuth
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response.Token
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	db := pgtest.Open(t)
+	router := newTestRouter(t, db)
+	existing := db.NewUser().Create(t)
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"register", "/v1/register", dto.UserRequestPayload{Email: "new@example.com", Password: "password123"}, http.StatusCreated},
+		{"register taken email", "/v1/register", dto.UserRequestPayload{Email: existing.Email, Password: "password123"}, http.StatusConflict},
+		{"register invalid email", "/v1/register", dto.UserRequestPayload{Email: "not-an-email", Password: "password123"}, http.StatusBadRequest},
+		{"register short password", "/v1/register", dto.UserRequestPayload{Email: "short@example.com", Password: "short"}, http.StatusBadRequest},
+		{"register malformed body", "/v1/register", "{", http.StatusBadRequest},
+		{"login", "/v1/login", dto.UserRequestPayload{Email: existing.Email, Password: existing.Password}, http.StatusOK},
+		{"login wrong password", "/v1/login", dto.UserRequestPayload{Email: existing.Email, Password: "wrong-password"}, http.StatusBadRequest},
+		{"login unknown email", "/v1/login", dto.UserRequestPayload{Email: "nobody@example.com", Password: "password123"}, http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(t, router, http.MethodPost, tc.path, "", tc.body)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tc.status, rec.Body)
+			}
+		})
+	}
+
+	// The account created above can log in right away
+	token := login(t, router, pgtest.User{Email: "new@example.com", Password: "password123"})
+	if token == "" {
+		t.Fatal("login returned no token")
+	}
+}
+
+func TestGetUserRequiresToken(t *testing.T) {
+	db := pgtest.Open(t)
+	router := newTestRouter(t, db)
+	user := db.NewUser().Name("Jane").Create(t)
+	token := login(t, router, user)
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"invalid token", "not-a-jwt", http.StatusUnauthorized},
+		{"valid token", token, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(t, router, http.MethodGet, "/v1/user", tc.token, nil)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tc.status, rec.Body)
+			}
+		})
+	}
+
+	rec := serve(t, router, http.MethodGet, "/v1/user", token, nil)
+	var profile dto.ResponseGetProfile
+	if err := json.Unmarshal(rec.Body.Bytes(), &profile); err != nil {
+		t.Fatal(err)
+	}
+	if profile.Email != user.Email || profile.Name == nil || *profile.Name != "Jane" {
+		t.Fatalf("profile = %+v, want %s named Jane", profile, user.Email)
+	}
+}
+
+func TestGetActivities(t *testing.T) {
+	db := pgtest.Open(t)
+	router := newTestRouter(t, db)
+	jane := db.NewUser().Create(t)
+	john := db.NewUser().Create(t)
+	token := login(t, router, jane)
+
+	day := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	walk := db.NewActivity(jane.Id).Type("Walking").DoneAt(day).Calories(120).Create(t)
+	run := db.NewActivity(jane.Id).Type("Running").DoneAt(day.Add(48 * time.Hour)).Calories(300).Create(t)
+	yoga := db.NewActivity(jane.Id).Type("Yoga").DoneAt(day.Add(24 * time.Hour)).Calories(80).Create(t)
+	db.NewActivity(john.Id).Type("Running").DoneAt(day).Calories(500).Create(t)
+
+	from := day.Add(12 * time.Hour).Format(time.RFC3339)
+	to := day.Add(36 * time.Hour).Format(time.RFC3339)
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"most recent first", "", []string{run, yoga, walk}},
+		{"limit and offset", "?limit=1&offset=1", []string{yoga}},
+		{"offset past the end", "?offset=3", []string{}},
+		{"negative limit and offset are ignored", "?limit=-1&offset=-1", []string{run, yoga, walk}},
+		{"activity type", "?activityType=Running", []string{run}},
+		{"unknown activity type is ignored", "?activityType=Sleeping", []string{run, yoga, walk}},
+		{"done at range", "?doneAtFrom=" + from + "&doneAtTo=" + to, []string{yoga}},
+		{"calories range", "?caloriesBurnedMin=100&caloriesBurnedMax=200", []string{walk}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(t, router, http.MethodGet, "/v1/activity"+tc.query, token, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+			var activities []dto.ResponseActivity
+			if err := json.Unmarshal(rec.Body.Bytes(), &activities); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(activities))
+			for i, activity := range activities {
+				got[i] = activity.Id
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("activities = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if rec := serve(t, router, http.MethodGet, "/v1/activity", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestActivityDatesFollowUserTimezone(t *testing.T) {
+	db := pgtest.Open(t)
+	router := newTestRouter(t, db)
+	jane := db.NewUser().Timezone("Asia/Jakarta").Create(t)
+	token := login(t, router, jane)
+
+	// 2 October, 03:00 in Jakarta
+	walk := db.NewActivity(jane.Id).DoneAt(time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)).Create(t)
+
+	list := func(t *testing.T) []dto.ResponseActivity {
+		t.Helper()
+		rec := serve(t, router, http.MethodGet, "/v1/activity?doneAtFrom=2026-10-02&doneAtTo=2026-10-02", token, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+		}
+		var activities []dto.ResponseActivity
+		if err := json.Unmarshal(rec.Body.Bytes(), &activities); err != nil {
+			t.Fatal(err)
+		}
+		return activities
+	}
+
+	activities := list(t)
+	if len(activities) != 1 || activities[0].Id != walk {
+		t.Fatalf("activities = %+v, want %s", activities, walk)
+	}
+	if _, offset := activities[0].DoneAt.Zone(); offset != 7*60*60 {
+		t.Fatalf("doneAt = %s, want a +07:00 offset", activities[0].DoneAt)
+	}
+
+	if rec := serve(t, router, http.MethodPut, "/v1/user/timezone", token, dto.RequestUpdateTimezone{Timezone: "Mars/Olympus"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown timezone: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	rec := serve(t, router, http.MethodPut, "/v1/user/timezone", token, dto.RequestUpdateTimezone{Timezone: "UTC"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update timezone: status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	// In UTC the walk happened on 1 October
+	if activities := list(t); len(activities) != 0 {
+		t.Fatalf("activities = %+v, want none", activities)
+	}
+}
+
+func TestCreateActivityBatch(t *testing.T) {
+	db := pgtest.Open(t)
+	router := newTestRouter(t, db)
+	token := login(t, router, db.NewUser().Create(t))
+
+	items := []json.RawMessage{
+		json.RawMessage(`{"activityType": "Running", "doneAt": "2026-10-01T07:00:00+07:00", "durationInMinutes": 30}`),
+		json.RawMessage(`{"activityType": "Sleeping", "doneAt": "2026-10-01T07:00:00Z", "durationInMinutes": 30}`),
+		json.RawMessage(`{"activityType": "Walking", "doneAt": "yesterday", "durationInMinutes": 0}`),
+		json.RawMessage(`{"activityType": "Walking", "doneAt": "2026-10-01T07:00:00Z", "durationInMinutes": "long"}`),
+	}
+	batch := func(t *testing.T, body interface{}, status int) dto.ResponseActivityBatch {
+		t.Helper()
+		rec := serve(t, router, http.MethodPost, "/v1/activity/batch", token, body)
+		if rec.Code != status {
+			t.Fatalf("status = %d, want %d, body %s", rec.Code, status, rec.Body)
+		}
+		var response dto.ResponseActivityBatch
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+	stored := func(t *testing.T) int {
+		t.Helper()
+		var activities []dto.ResponseActivity
+		rec := serve(t, router, http.MethodGet, "/v1/activity?limit=10", token, nil)
+		if err := json.Unmarshal(rec.Body.Bytes(), &activities); err != nil {
+			t.Fatal(err)
+		}
+		return len(activities)
+	}
+
+	// A single invalid activity rejects the whole batch
+	response := batch(t, dto.RequestActivityBatch{Activities: items, AllOrNothing: true}, http.StatusBadRequest)
+	if response.Created != 0 || response.Failed != 3 || stored(t) != 0 {
+		t.Fatalf("all or nothing: created %d, failed %d, stored %d, want 0, 3, 0", response.Created, response.Failed, stored(t))
+	}
+
+	response = batch(t, dto.RequestActivityBatch{Activities: items}, http.StatusOK)
+	if response.Created != 1 || response.Failed != 3 {
+		t.Fatalf("created %d, failed %d, want 1 and 3", response.Created, response.Failed)
+	}
+	if r := response.Results[0]; r.ActivityId == "" || r.CaloriesBurned != 300 {
+		t.Fatalf("result 0 = %+v, want an id and 300 calories", r)
+	}
+	for i, fields := range [][]string{1: {"activityType"}, 2: {"doneAt", "durationInMinutes"}, 3: {"durationInMinutes"}} {
+		if i == 0 {
+			continue
+		}
+		got := make([]string, 0, len(response.Results[i].Errors))
+		for field := range response.Results[i].Errors {
+			got = append(got, field)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, fields) {
+			t.Fatalf("result %d errors = %v, want fields %v", i, response.Results[i].Errors, fields)
+		}
+	}
+	if n := stored(t); n != 1 {
+		t.Fatalf("stored %d activities, want 1", n)
+	}
+
+	tooMany := make([]json.RawMessage, 101)
+	for i := range tooMany {
+		tooMany[i] = items[0]
+	}
+	if rec := serve(t, router, http.MethodPost, "/v1/activity/batch", token, dto.RequestActivityBatch{Activities: tooMany}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("101 activities: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestUnknownRoute(t *testing.T) {
+	db := pgtest.Open(t)
+	router := newTestRouter(t, db)
+	if rec := serve(t, router, http.MethodGet, "/v1/nothing-here", "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -1,19 +1,38 @@
 package server
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/TimDebug/FitByte/config"
-	dbcontext "github.com/TimDebug/FitByte/database"
+	"github.com/TimDebug/FitByte/di"
 	"github.com/TimDebug/FitByte/helper"
+	"github.com/TimDebug/FitByte/logger"
 	"github.com/TimDebug/FitByte/middleware"
+	"github.com/TimDebug/FitByte/tracing"
 	"github.com/gin-gonic/gin"
+	"github.com/samber/do/v2"
 )
 
-func Start() error {
-	config := config.LoadConfig()
+type Server struct {
+	cfg   config.ServerConfig
+	http  *http.Server
+	ready atomic.Bool
+
+	// admin serves /metrics and the log levels, nil when it has no
+	// address
+	admin *http.Server
+}
+
+// New builds the router and the HTTP server, call Start to serve.
+func New() *Server {
+	cfg := do.MustInvoke[*config.Config](di.Injector)
 
 	wd, err := os.Getwd()
 	if err != nil {
@@ -21,46 +40,129 @@ func Start() error {
 	}
 	helper.WORK_DIR = wd
 
-	r := gin.Default()
-	r.Use(middleware.EnableCORS)
+	reloader := do.MustInvoke[*config.Reloader](di.Injector)
+	runtime := reloader.Runtime()
+	cors := middleware.NewCORS(runtime.CORSAllowedOrigins)
+	rateLimiter := middleware.NewRateLimiter(runtime.RateLimitPerSecond, runtime.RateLimitBurst)
+	reloader.Subscribe(func(runtime config.RuntimeConfig) {
+		cors.SetAllowedOrigins(runtime.CORSAllowedOrigins)
+		rateLimiter.SetLimit(runtime.RateLimitPerSecond, runtime.RateLimitBurst)
+	})
 
-	NewRouter(r, dbcontext.Connect(config.DatabaseURL))
+	switch cfg.Server.Mode {
+	case config.ModeProduction:
+		gin.SetMode(gin.ReleaseMode)
+	default:
+		gin.SetMode(gin.DebugMode)
+	}
 
+	// Sets the global tracer provider and propagator before any span starts
+	do.MustInvoke[*tracing.Provider](di.Injector)
+
+	r := gin.New()
+	// ClientIP keys the rate limits, only proxies we run may name the client
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		panic(err)
+	}
 	r.Use(gin.Recovery())
+	// Let *gin.Context hand out values of the request context, e.g. the span
+	r.ContextWithFallback = true
+	s := &Server{
+		cfg: cfg.Server,
+		http: &http.Server{
+			Addr:    cfg.Server.Addr(),
+			Handler: r,
+		},
+	}
 
-	port := os.Getenv("PORT")
+	// Probes are registered before CORS and rate limiting, the orchestrator
+	// must never be throttled
+	s.registerHealthRoutes(r, readinessChecker(di.Injector, cfg.Server.HealthCheckTimeout))
 
-	if len(port) == 0 {
-		port = "8080"
+	logHandler := do.MustInvoke[logger.LogHandler](di.Injector)
+	if cfg.Metrics.Enabled {
+		registerCollectors(di.Injector)
 	}
+	if cfg.Metrics.Addr != "" {
+		s.admin = newAdminServer(cfg.Metrics.Addr, cfg.Metrics.Enabled, &logHandler)
+	}
+	r.Use(
+		middleware.Tracing,
+		middleware.RequestLogger(&logHandler),
+		middleware.Metrics,
+		cors.Handle,
+		rateLimiter.Handle,
+	)
 
-	appEnv := os.Getenv("MODE")
+	NewRouter(r, di.Injector)
 
-	switch appEnv {
-	case "PRODUCTION":
-		gin.SetMode(gin.ReleaseMode)
+	return s
+}
 
-		sslCert := os.Getenv("SSL_CERT_PATH")
-		sslKey := os.Getenv("SSL_KEY_PATH")
+// Start serves until Shutdown is called, it then returns nil.
+func (s *Server) Start() error {
+	if s.admin != nil {
+		go func() {
+			if err := s.admin.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Admin server stopped: %v", err)
+			}
+		}()
+	}
 
-		if sslCert == "" || sslKey == "" {
-			log.Fatal("SSL certificates not configured")
-		}
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(ln)
+}
 
-		host := os.Getenv("PROD_HOST")
-		err := r.RunTLS(
-			fmt.Sprintf("%s:%s", host, port),
-			sslCert,
-			sslKey,
-		)
-		if err != nil {
-			log.Fatalf("Failed to start HTTPS server: %v", err)
-		}
+// serve is Start on a listener of the caller's.
+func (s *Server) serve(ln net.Listener) error {
+	s.ready.Store(true)
+
+	var err error
+	switch s.cfg.Mode {
+	case config.ModeProduction:
+		err = s.http.ServeTLS(ln, s.cfg.SSLCertPath, s.cfg.SSLKeyPath)
 	default:
-		gin.SetMode(gin.DebugMode)
-		host := os.Getenv("DEBUG_HOST")
-		r.Run(fmt.Sprintf("%s:%s", host, port))
+		err = s.http.Serve(ln)
+	}
+
+	s.ready.Store(false)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Ready reports whether the server accepts traffic, it turns false as soon
+// as Shutdown is called.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Shutdown reports not ready, waits for the configured ShutdownDelay and then
+// stops accepting connections and waits for in-flight requests for
+// ShutdownTimeout, or until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.ready.Store(false)
+
+	if s.cfg.ShutdownDelay > 0 {
+		log.Printf("Not ready, waiting %s before draining connections", s.cfg.ShutdownDelay)
+		select {
+		case <-time.After(s.cfg.ShutdownDelay):
+		case <-ctx.Done():
+		}
 	}
 
-	return nil
+	// The delay does not eat into the time requests get to finish
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	err := s.http.Shutdown(ctx)
+	if s.admin != nil {
+		// Keep serving metrics while draining, the last scrape shows it
+		err = errors.Join(err, s.admin.Shutdown(ctx))
+	}
+	return err
 }
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/health"
+	"github.com/gin-gonic/gin"
+)
+
+func TestShutdownFailsReadinessAndDrainsRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	s := &Server{
+		cfg:  config.ServerConfig{ShutdownDelay: 300 * time.Millisecond, ShutdownTimeout: 5 * time.Second},
+		http: &http.Server{Handler: r},
+	}
+	s.registerHealthRoutes(r, health.NewChecker(time.Second))
+	entered, release := make(chan struct{}), make(chan struct{})
+	r.GET("/slow", func(ctx *gin.Context) {
+		close(entered)
+		<-release
+		ctx.Status(http.StatusOK)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.serve(ln) }()
+	base := "http://" + ln.Addr().String()
+	if code := getStatus(t, base+"/readyz"); code != http.StatusOK {
+		t.Fatalf("/readyz before shutdown = %d, want 200", code)
+	}
+
+	slow := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			t.Errorf("in-flight request: %v", err)
+			slow <- 0
+			return
+		}
+		resp.Body.Close()
+		slow <- resp.StatusCode
+	}()
+	<-entered
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+
+	// Load balancers see the replica leave while it still accepts requests
+	deadline := time.Now().Add(s.cfg.ShutdownDelay)
+	for getStatus(t, base+"/readyz") != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("/readyz kept succeeding through the shutdown delay")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v with a request in flight", err)
+	case <-time.After(2 * s.cfg.ShutdownDelay):
+	}
+	close(release)
+	if code := <-slow; code != http.StatusOK {
+		t.Fatalf("in-flight request = %d, want 200", code)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+}
+
+func TestShutdownTimeoutStartsAfterTheDelay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	s := &Server{
+		cfg:  config.ServerConfig{ShutdownDelay: 300 * time.Millisecond, ShutdownTimeout: 200 * time.Millisecond},
+		http: &http.Server{Handler: r},
+	}
+	entered := make(chan struct{})
+	r.GET("/slow", func(ctx *gin.Context) {
+		close(entered)
+		// Longer than the timeout, shorter than delay and timeout together
+		time.Sleep(400 * time.Millisecond)
+		ctx.Status(http.StatusOK)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.serve(ln)
+	slow := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			t.Errorf("in-flight request: %v", err)
+			slow <- 0
+			return
+		}
+		resp.Body.Close()
+		slow <- resp.StatusCode
+	}()
+	<-entered
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if code := <-slow; code != http.StatusOK {
+		t.Fatalf("in-flight request = %d, want 200", code)
+	}
+}
+
+func getStatus(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
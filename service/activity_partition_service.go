@@ -0,0 +1,303 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/domain"
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/helper"
+	"github.com/TimDebug/FitByte/logger"
+	"github.com/TimDebug/FitByte/repository"
+	"github.com/samber/do/v2"
+)
+
+type PartitionOptions struct {
+	Premake         int
+	RetentionMonths int
+	Archive         bool
+	ArchivePrefix   string
+}
+
+func NewPartitionOptions(cfg config.PartitionConfig) PartitionOptions {
+	return PartitionOptions{
+		Premake:         cfg.Premake,
+		RetentionMonths: cfg.RetentionMonths,
+		Archive:         cfg.Archive,
+		ArchivePrefix:   cfg.ArchivePrefix,
+	}
+}
+
+// ActivityPartitionService keeps a partition of activities ready for the
+// coming months and drops, optionally archiving, the expired ones.
+type ActivityPartitionService struct {
+	repo      repository.ActivityPartitionRepository
+	storage   domain.StorageClient
+	namespace *cache.UserNamespace
+	logger    logger.LogHandler
+	now       func() time.Time
+
+	mu      sync.Mutex
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func NewActivityPartitionService(
+	repo repository.ActivityPartitionRepository,
+	storage domain.StorageClient,
+	store cache.Store,
+	logger logger.LogHandler,
+) *ActivityPartitionService {
+	return &ActivityPartitionService{
+		repo:      repo,
+		storage:   storage,
+		namespace: cache.NewUserNamespace(store),
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func NewActivityPartitionServiceInject(i do.Injector) (*ActivityPartitionService, error) {
+	_repo := do.MustInvoke[repository.ActivityPartitionRepository](i)
+	_storage := do.MustInvoke[domain.StorageClient](i)
+	_cache := do.MustInvoke[cache.Store](i)
+	_logger := do.MustInvoke[logger.LogHandler](i)
+	return NewActivityPartitionService(_repo, _storage, _cache, _logger), nil
+}
+
+// Maintain creates the partitions from the current month to opts.Premake
+// months ahead and drops those older than opts.RetentionMonths, along with
+// the expired rows of activities_default. A partition whose archive cannot be
+// uploaded is kept for the next run. Instances
+// maintaining at the same time take turns.
+func (s *ActivityPartitionService) Maintain(ctx context.Context, opts PartitionOptions) (*dto.PartitionMaintenanceReport, error) {
+	report := &dto.PartitionMaintenanceReport{
+		Created: make([]string, 0),
+		Dropped: make([]dto.ArchivedPartition, 0),
+	}
+
+	unlock, err := s.repo.Lock(ctx)
+	if err != nil {
+		s.logger.For(ctx).Error(err.Error(), helper.ActivityPartitionServiceMaintain)
+		return nil, err
+	}
+	defer unlock()
+
+	now := s.now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	existing, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.For(ctx).Error(err.Error(), helper.ActivityPartitionServiceMaintain)
+		return nil, err
+	}
+	known := make(map[time.Time]bool, len(existing))
+	for _, partition := range existing {
+		known[partition.Month] = true
+	}
+
+	for ahead := 0; ahead <= opts.Premake; ahead++ {
+		month := currentMonth.AddDate(0, ahead, 0)
+		if known[month] {
+			continue
+		}
+		partition, err := s.repo.Create(ctx, month)
+		if err != nil {
+			s.logger.For(ctx).Error(err.Error(), helper.ActivityPartitionServiceMaintain)
+			return report, err
+		}
+		report.Created = append(report.Created, partition.Name)
+	}
+
+	if opts.RetentionMonths > 0 {
+		cutoff := currentMonth.AddDate(0, -opts.RetentionMonths, 0)
+		remaining := make(map[time.Time]bool)
+		for _, partition := range existing {
+			// A detached partition is left by a run that failed to archive it
+			if !partition.Detached && partition.End().After(cutoff) {
+				continue
+			}
+			if !s.expire(ctx, partition, opts, report) {
+				remaining[partition.Month] = true
+			}
+		}
+
+		// Rows of a month written after its partition was dropped land in
+		// activities_default, a new partition takes them out to be dropped.
+		months, err := s.repo.DefaultMonths(ctx, cutoff)
+		if err != nil {
+			s.logger.For(ctx).Error(err.Error(), helper.ActivityPartitionServiceMaintain)
+			return report, err
+		}
+		for _, month := range months {
+			// The partition left over would not take the rows
+			if remaining[month] {
+				continue
+			}
+			partition, err := s.repo.Create(ctx, month)
+			if err != nil {
+				s.logger.For(ctx).Error(err.Error(), helper.ActivityPartitionServiceMaintain)
+				return report, err
+			}
+			s.expire(ctx, partition, opts, report)
+		}
+	}
+
+	s.logger.For(ctx).Info("partition maintenance finished", helper.ActivityPartitionServiceMaintain, report)
+	return report, nil
+}
+
+// expire drops partition and records the outcome in report, it returns
+// whether the partition is gone.
+func (s *ActivityPartitionService) expire(ctx context.Context, partition repository.ActivityPartition, opts PartitionOptions, report *dto.PartitionMaintenanceReport) bool {
+	dropped, err := s.drop(ctx, partition, opts)
+	if err != nil {
+		s.logger.For(ctx).Warn(err.Error(), helper.ActivityPartitionServiceMaintain, partition.Name)
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", partition.Name, err))
+		return false
+	}
+	report.Dropped = append(report.Dropped, dropped)
+	return true
+}
+
+// drop detaches partition before archiving it, so no row is written to it
+// after the export, then drops it. A partition whose archive fails stays
+// detached until the next run.
+func (s *ActivityPartitionService) drop(ctx context.Context, partition repository.ActivityPartition, opts PartitionOptions) (dto.ArchivedPartition, error) {
+	dropped := dto.ArchivedPartition{Partition: partition.Name}
+	if !partition.Detached {
+		if err := s.repo.Detach(ctx, partition); err != nil {
+			return dropped, err
+		}
+		partition.Detached = true
+	}
+
+	if opts.Archive {
+		key, err := s.archiveKey(ctx, partition, opts)
+		if err != nil {
+			return dropped, err
+		}
+		dropped.Key = key
+		rows, err := s.archive(ctx, partition, dropped.Key)
+		if err != nil {
+			return dropped, err
+		}
+		dropped.Rows = rows
+	}
+
+	userIds, err := s.repo.Drop(ctx, partition, dropped.Key)
+	if err != nil {
+		return dropped, err
+	}
+	// Cached lists and ETags of these users still show the dropped rows
+	for _, userId := range userIds {
+		if err := s.namespace.Bump(ctx, userId); err != nil {
+			s.logger.For(ctx).Warn(err.Error(), helper.ActivityPartitionServiceMaintain, userId)
+		}
+	}
+	return dropped, nil
+}
+
+// archiveKey returns opts.ArchivePrefix + YYYY-MM.csv.gz, numbered from -2
+// when a month is archived again, so an earlier archive is never overwritten.
+func (s *ActivityPartitionService) archiveKey(ctx context.Context, partition repository.ActivityPartition, opts PartitionOptions) (string, error) {
+	base := opts.ArchivePrefix + partition.Month.Format("2006-01")
+	files, err := s.storage.ListFiles(ctx, base)
+	if err != nil {
+		return "", fmt.Errorf("list archives: %w", err)
+	}
+	taken := make(map[string]bool, len(files))
+	for _, file := range files {
+		taken[file.Key] = true
+	}
+	key := base + ".csv.gz"
+	for n := 2; taken[key]; n++ {
+		key = fmt.Sprintf("%s-%d.csv.gz", base, n)
+	}
+	return key, nil
+}
+
+// archive streams the gzipped export of partition to storage, the partition
+// is never held in memory.
+func (s *ActivityPartitionService) archive(ctx context.Context, partition repository.ActivityPartition, key string) (int64, error) {
+	pr, pw := io.Pipe()
+	var rows int64
+	exported := make(chan error, 1)
+	go func() {
+		zw := gzip.NewWriter(pw)
+		var err error
+		rows, err = s.repo.Export(ctx, partition, zw)
+		if err == nil {
+			err = zw.Close()
+		}
+		// A nil error ends the upload, any other aborts it
+		pw.CloseWithError(err)
+		exported <- err
+	}()
+
+	_, uploadErr := s.storage.PutStream(ctx, key, "application/gzip", pr, false)
+	// Unblocks the export when the upload gave up early
+	pr.CloseWithError(errors.New("archive upload stopped"))
+	exportErr := <-exported
+	if uploadErr != nil {
+		// Carries the export error when that aborted the upload
+		return 0, fmt.Errorf("upload archive: %w", uploadErr)
+	}
+	if exportErr != nil {
+		return 0, exportErr
+	}
+	return rows, nil
+}
+
+// Schedule runs Maintain now and then every cfg.Interval until Stop is
+// called.
+func (s *ActivityPartitionService) Schedule(cfg config.PartitionConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
+
+	opts := NewPartitionOptions(cfg)
+	go func(stop <-chan struct{}, stopped chan<- struct{}) {
+		defer close(stopped)
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.Maintain(context.Background(), opts); err != nil {
+				s.logger.Error(err.Error(), helper.ActivityPartitionServiceSchedule)
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}(s.stop, s.stopped)
+}
+
+// Stop halts the scheduled maintenance and waits for a running one to return.
+func (s *ActivityPartitionService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.stopped
+	s.stop = nil
+	s.stopped = nil
+}
+
+// Shutdown stops the scheduled maintenance when the injector shuts down.
+func (s *ActivityPartitionService) Shutdown() {
+	s.Stop()
+}
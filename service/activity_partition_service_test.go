@@ -0,0 +1,298 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/domain"
+	"github.com/TimDebug/FitByte/repository"
+)
+
+type fakePartitionRepo struct {
+	partitions []repository.ActivityPartition
+	dropped    map[string]string // partition name to archive key
+	// defaults holds the month of each row in activities_default
+	defaults    []time.Time
+	calls       []string
+	exportErr   error
+	afterExport func()
+	locked      bool
+}
+
+func (r *fakePartitionRepo) Create(ctx context.Context, month time.Time) (repository.ActivityPartition, error) {
+	partition := repository.ActivityPartition{Name: "activities_p" + month.Format("2006_01"), Month: month}
+	r.partitions = append(r.partitions, partition)
+	r.defaults = slices.DeleteFunc(r.defaults, month.Equal)
+	return partition, nil
+}
+
+func (r *fakePartitionRepo) List(ctx context.Context) ([]repository.ActivityPartition, error) {
+	return slices.Clone(r.partitions), nil
+}
+
+func (r *fakePartitionRepo) Detach(ctx context.Context, partition repository.ActivityPartition) error {
+	r.calls = append(r.calls, "detach "+partition.Name)
+	for i := range r.partitions {
+		if r.partitions[i].Name == partition.Name {
+			r.partitions[i].Detached = true
+		}
+	}
+	return nil
+}
+
+func (r *fakePartitionRepo) Export(ctx context.Context, partition repository.ActivityPartition, w io.Writer) (int64, error) {
+	r.calls = append(r.calls, "export "+partition.Name)
+	_, err := fmt.Fprintf(w, "id,done_at\n1,%s\n", partition.Month.Format(time.DateOnly))
+	if r.afterExport != nil {
+		r.afterExport()
+	}
+	if r.exportErr != nil {
+		return 0, r.exportErr
+	}
+	return 1, err
+}
+
+func (r *fakePartitionRepo) Drop(ctx context.Context, partition repository.ActivityPartition, archiveKey string) ([]string, error) {
+	r.calls = append(r.calls, "drop "+partition.Name)
+	if !partition.Detached {
+		return nil, errors.New("the partition is attached")
+	}
+	r.partitions = slices.DeleteFunc(r.partitions, func(p repository.ActivityPartition) bool { return p.Name == partition.Name })
+	r.dropped[partition.Name] = archiveKey
+	return []string{"user-1"}, nil
+}
+
+func (r *fakePartitionRepo) DefaultMonths(ctx context.Context, before time.Time) ([]time.Time, error) {
+	var months []time.Time
+	for _, month := range r.defaults {
+		if month.Before(before) && !slices.ContainsFunc(months, month.Equal) {
+			months = append(months, month)
+		}
+	}
+	return months, nil
+}
+
+func (r *fakePartitionRepo) Lock(ctx context.Context) (func(), error) {
+	if r.locked {
+		return nil, errors.New("already locked")
+	}
+	r.locked = true
+	return func() { r.locked = false }, nil
+}
+
+type fakeStorage struct {
+	domain.StorageClient
+	files   map[string][]byte
+	failing string
+}
+
+func (s *fakeStorage) PutStream(ctx context.Context, key, mimeType string, body io.Reader, isPublic bool) (string, error) {
+	if key == s.failing {
+		return "", errors.New("storage unavailable")
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	s.files[key] = content
+	return key, nil
+}
+
+func (s *fakeStorage) ListFiles(ctx context.Context, prefix string) ([]domain.StoredFile, error) {
+	var files []domain.StoredFile
+	for key, content := range s.files {
+		if strings.HasPrefix(key, prefix) {
+			files = append(files, domain.StoredFile{Key: key, Size: int64(len(content))})
+		}
+	}
+	return files, nil
+}
+
+func newTestPartitionService(t *testing.T, months ...time.Time) (*ActivityPartitionService, *fakePartitionRepo, *fakeStorage) {
+	repo := &fakePartitionRepo{dropped: make(map[string]string)}
+	for _, month := range months {
+		repo.Create(context.Background(), month)
+	}
+	storage := &fakeStorage{files: make(map[string][]byte)}
+	s := NewActivityPartitionService(repo, storage, newTestStore(t), newTestLogger())
+	s.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+	return s, repo, storage
+}
+
+func TestMaintainCreatesUpcomingPartitions(t *testing.T) {
+	s, repo, _ := newTestPartitionService(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+
+	report, err := s.Maintain(context.Background(), PartitionOptions{Premake: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"activities_p2026_11", "activities_p2026_12", "activities_p2027_01"}
+	if !slices.Equal(report.Created, want) {
+		t.Fatalf("created %v, want %v", report.Created, want)
+	}
+	if len(repo.partitions) != 4 || len(report.Dropped) != 0 {
+		t.Fatalf("partitions = %v, dropped %v", repo.partitions, report.Dropped)
+	}
+
+	report, _ = s.Maintain(context.Background(), PartitionOptions{Premake: 3})
+	if len(report.Created) != 0 {
+		t.Fatalf("second run created %v", report.Created)
+	}
+}
+
+func TestMaintainArchivesExpiredPartitions(t *testing.T) {
+	s, repo, storage := newTestPartitionService(t,
+		time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC),
+	)
+
+	// October keeps three months back, July included
+	report, err := s.Maintain(context.Background(), PartitionOptions{Premake: 1, RetentionMonths: 3, Archive: true, ArchivePrefix: "archive/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Dropped) != 1 || report.Dropped[0].Key != "archive/2026-06.csv.gz" || report.Dropped[0].Rows != 1 {
+		t.Fatalf("dropped %+v, want only June", report.Dropped)
+	}
+	if repo.dropped["activities_p2026_06"] != "archive/2026-06.csv.gz" {
+		t.Errorf("June dropped with archive %q", repo.dropped["activities_p2026_06"])
+	}
+	if _, ok := repo.dropped["activities_p2026_07"]; ok || len(report.Errors) != 0 {
+		t.Errorf("July is within the retention: dropped %v, errors %v", repo.dropped, report.Errors)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(storage.files["archive/2026-06.csv.gz"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, _ := io.ReadAll(zr)
+	if string(csv) != "id,done_at\n1,2026-06-01\n" {
+		t.Errorf("archive = %q", csv)
+	}
+	if repo.locked {
+		t.Error("maintenance lock is still held")
+	}
+}
+
+func TestMaintainBumpsUsersOfDroppedPartitions(t *testing.T) {
+	s, _, _ := newTestPartitionService(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	before, err := s.namespace.Version(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Maintain(context.Background(), PartitionOptions{RetentionMonths: 1}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := s.namespace.Version(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == before {
+		t.Fatal("cached data of the dropped activities is still current")
+	}
+}
+
+func TestMaintainKeepsPartitionWhenExportFails(t *testing.T) {
+	s, repo, storage := newTestPartitionService(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	repo.exportErr = errors.New("connection reset")
+
+	report, err := s.Maintain(context.Background(), PartitionOptions{RetentionMonths: 1, Archive: true, ArchivePrefix: "archive/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 1 || len(report.Dropped) != 0 {
+		t.Fatalf("report = %+v, want the export error", report)
+	}
+	if _, ok := storage.files["archive/2026-01.csv.gz"]; ok {
+		t.Fatal("a partial archive was stored")
+	}
+	if _, ok := repo.dropped["activities_p2026_01"]; ok {
+		t.Fatal("partition dropped without its archive")
+	}
+	if !repo.partitions[0].Detached {
+		t.Fatalf("partitions = %+v, want January left detached", repo.partitions)
+	}
+}
+
+func TestMaintainKeepsPartitionWhenUploadFails(t *testing.T) {
+	s, repo, storage := newTestPartitionService(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage.failing = "archive/2026-01.csv.gz"
+
+	report, err := s.Maintain(context.Background(), PartitionOptions{Premake: 0, RetentionMonths: 1, Archive: true, ArchivePrefix: "archive/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 1 || len(report.Dropped) != 0 {
+		t.Fatalf("report = %+v, want the upload error", report)
+	}
+	if _, ok := repo.dropped["activities_p2026_01"]; ok {
+		t.Fatal("partition dropped without its archive")
+	}
+	if !repo.partitions[0].Detached {
+		t.Fatalf("partitions = %+v, want January left detached", repo.partitions)
+	}
+
+	// The next run archives the detached partition although it is listed
+	// as is
+	storage.failing = ""
+	report, err = s.Maintain(context.Background(), PartitionOptions{Premake: 0, RetentionMonths: 1, Archive: true, ArchivePrefix: "archive/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Dropped) != 1 || repo.dropped["activities_p2026_01"] != "archive/2026-01.csv.gz" {
+		t.Fatalf("report = %+v, dropped %v", report, repo.dropped)
+	}
+}
+
+func TestMaintainDetachesBeforeExporting(t *testing.T) {
+	s, repo, _ := newTestPartitionService(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	if _, err := s.Maintain(context.Background(), PartitionOptions{RetentionMonths: 1, Archive: true, ArchivePrefix: "archive/"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"detach activities_p2026_01", "export activities_p2026_01", "drop activities_p2026_01"}
+	if !slices.Equal(repo.calls, want) {
+		t.Fatalf("calls = %v, want %v", repo.calls, want)
+	}
+}
+
+func TestMaintainExpiresRowsOfDroppedMonths(t *testing.T) {
+	january := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, repo, storage := newTestPartitionService(t, january)
+	// A row of January written while its partition is being dropped goes to
+	// activities_default
+	repo.afterExport = func() {
+		repo.afterExport = nil
+		repo.defaults = append(repo.defaults, january)
+	}
+	// Still within the retention
+	repo.defaults = append(repo.defaults, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC))
+
+	report, err := s.Maintain(context.Background(), PartitionOptions{RetentionMonths: 1, Archive: true, ArchivePrefix: "archive/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Dropped) != 2 || len(report.Errors) != 0 {
+		t.Fatalf("report = %+v, want January dropped twice", report)
+	}
+	if report.Dropped[0].Key != "archive/2026-01.csv.gz" || report.Dropped[1].Key != "archive/2026-01-2.csv.gz" {
+		t.Errorf("archives %q and %q", report.Dropped[0].Key, report.Dropped[1].Key)
+	}
+	if len(storage.files) != 2 {
+		t.Errorf("stored %d archives, want 2", len(storage.files))
+	}
+	if len(repo.defaults) != 1 || !repo.defaults[0].Equal(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("activities_default holds %v, want September only", repo.defaults)
+	}
+}
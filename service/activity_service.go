@@ -1,56 +1,169 @@
 package service
 
 import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/TimDebug/FitByte/cache"
 	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/entity"
 	"github.com/TimDebug/FitByte/helper"
 	"github.com/TimDebug/FitByte/logger"
+	"github.com/TimDebug/FitByte/metrics"
 	"github.com/TimDebug/FitByte/repository"
-	"github.com/gin-gonic/gin"
+	"github.com/TimDebug/FitByte/tracing"
 	"github.com/samber/do/v2"
 )
 
 type ActivityService struct {
-	repo   repository.ActivityRepository
-	logger logger.LogHandler
+	repo       repository.ActivityRepository
+	users      repository.UserRepository
+	namespace  *cache.UserNamespace
+	profiles   *cache.Typed[dto.ResponseGetProfile]
+	activities *cache.Typed[[]dto.ResponseActivity]
+	logger     logger.LogHandler
 }
 
 func NewActivityService(
 	repo repository.ActivityRepository,
+	users repository.UserRepository,
+	store cache.Store,
 	logger logger.LogHandler,
 ) ActivityService {
 	return ActivityService{
-		repo:   repo,
-		logger: logger,
+		repo:       repo,
+		users:      users,
+		namespace:  cache.NewUserNamespace(store),
+		profiles:   newProfileCache(store),
+		activities: cache.NewTyped[[]dto.ResponseActivity]("activities", store, cache.JSONCodec),
+		logger:     logger,
 	}
 }
 
 func NewActivityServiceInject(i do.Injector) (ActivityService, error) {
 	_repo := do.MustInvoke[repository.ActivityRepository](i)
+	_users := do.MustInvoke[repository.UserRepository](i)
+	_cache := do.MustInvoke[cache.Store](i)
 	_logger := do.MustInvoke[logger.LogHandler](i)
-	return NewActivityService(_repo, _logger), nil
+	return NewActivityService(_repo, _users, _cache, _logger), nil
 }
 
-func (a *ActivityService) GetAll(ctx *gin.Context, queryArgs []interface{}) ([]dto.ResponseActivity, error) {
-	a.logger.Info("param", helper.ActivityServiceGetAll, queryArgs...)
-	rawActivities, err := a.repo.GetAll(ctx, queryArgs)
+// Location is the user's timezone, used to read date filters and to present
+// times. It is read from the profile cache UserService fills. Unknown users
+// and zones fall back to UTC.
+func (a *ActivityService) Location(ctx context.Context, userId string) (_ *time.Location, err error) {
+	ctx, span := tracing.Start(ctx, "ActivityService.Location")
+	defer func() { tracing.End(span, err) }()
+
+	var profile dto.ResponseGetProfile
+	version, err := a.namespace.Version(ctx, userId)
+	if err != nil {
+		a.logger.For(ctx).Warn(err.Error(), helper.ActivityServiceGetAll)
+		profile, err = a.loadProfile(ctx, userId)
+	} else {
+		key := fmt.Sprintf(cache.CacheUserIdToProfile, userId, version)
+		profile, err = a.profiles.GetOrLoad(ctx, key, cache.Ttl(), func(ctx context.Context) (dto.ResponseGetProfile, error) {
+			return a.loadProfile(ctx, userId)
+		})
+	}
 	if err != nil {
-		a.logger.Error(err.Error(), helper.ActivityServiceGetAll, rawActivities)
+		if errors.Is(err, helper.ErrNotFound) {
+			return time.UTC, nil
+		}
+		a.logger.For(ctx).Error(err.Error(), helper.ActivityServiceGetAll)
 		return nil, helper.NewErrorResponse(http.StatusInternalServerError, err.Error())
 	}
+	return loadLocation(profile.Timezone), nil
+}
+
+func (a *ActivityService) loadProfile(ctx context.Context, userId string) (dto.ResponseGetProfile, error) {
+	profile, err := a.users.GetProfile(ctx, userId)
+	if err != nil {
+		return dto.ResponseGetProfile{}, err
+	}
+	return profileResponse(profile), nil
+}
+
+func loadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// GetAll lists the user's activities, read through a cache keyed by the user,
+// their namespace version and the filters.
+func (a *ActivityService) GetAll(ctx context.Context, filter repository.ActivityFilter) (_ []dto.ResponseActivity, err error) {
+	ctx, span := tracing.Start(ctx, "ActivityService.GetAll")
+	defer func() { tracing.End(span, err) }()
+
+	a.logger.For(ctx).Debug("param", helper.ActivityServiceGetAll, filter)
+
+	version, err := a.namespace.Version(ctx, filter.UserId)
+	if err != nil {
+		a.logger.For(ctx).Warn(err.Error(), helper.ActivityServiceGetAll)
+		return a.load(ctx, filter)
+	}
 
+	key := fmt.Sprintf(cache.CacheActivitiesWithParams, filter.UserId, version, filterHash(filter))
+	return a.activities.GetOrLoad(ctx, key, cache.Ttl(), func(ctx context.Context) ([]dto.ResponseActivity, error) {
+		return a.load(ctx, filter)
+	})
+}
+
+func (a *ActivityService) load(ctx context.Context, filter repository.ActivityFilter) ([]dto.ResponseActivity, error) {
+	rawActivities, err := a.repo.GetAll(ctx, filter)
+	if err != nil {
+		a.logger.For(ctx).Error(err.Error(), helper.ActivityServiceGetAll)
+		return nil, helper.NewErrorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	loc := loadLocation(filter.Timezone)
 	returnedActivities := make([]dto.ResponseActivity, 0)
 	for _, elem := range rawActivities {
 		var activity dto.ResponseActivity
 		activity.Id = *elem.ActivityId
 		activity.ActivityType = *elem.ActivityType
-		activity.DoneAt = fmt.Sprintf("%d", *elem.DoneAt)
+		activity.DoneAt = elem.DoneAt.In(loc)
 		activity.DurationInMinutes = int(*elem.DurationInMinutes)
 		activity.CaloriesBurned = int(*elem.CaloriesBurned)
-		activity.CreatedAt = fmt.Sprintf("%d", *elem.CreatedAt)
+		activity.CreatedAt = elem.CreatedAt.In(loc)
 		returnedActivities = append(returnedActivities, activity)
 	}
 	return returnedActivities, nil
 }
+
+// CreateBatch stores activities for userId, all of them or none, and
+// returns their ids in order.
+func (a *ActivityService) CreateBatch(ctx context.Context, userId string, activities []entity.Activity) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "ActivityService.CreateBatch")
+	defer func() { tracing.End(span, err) }()
+
+	ids, err := a.repo.CreateBatch(ctx, userId, activities)
+	if err != nil {
+		a.logger.For(ctx).Error(err.Error(), helper.ActivityServiceCreateBatch)
+		return nil, helper.NewErrorResponse(http.StatusInternalServerError, err.Error())
+	}
+	metrics.ActivitiesLogged.Add(float64(len(ids)))
+
+	// Cached lists no longer include every activity of the user
+	if err := a.namespace.Bump(ctx, userId); err != nil {
+		a.logger.For(ctx).Warn(err.Error(), helper.ActivityServiceCreateBatch)
+	}
+	return ids, nil
+}
+
+// filterHash identifies the filter values, the pointers themselves differ
+// between requests.
+func filterHash(filter repository.ActivityFilter) string {
+	encoded, _ := json.Marshal(filter)
+	sum := sha1.Sum(encoded)
+	return hex.EncodeToString(sum[:8])
+}
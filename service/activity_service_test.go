@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/entity"
+	"github.com/TimDebug/FitByte/repository"
+)
+
+func addActivity(db *repository.Memory, userId, activityType string, doneAt time.Time, calories int64) string {
+	duration := int64(30)
+	return db.AddActivity(userId, entity.Activity{
+		ActivityType:      &activityType,
+		DoneAt:            &doneAt,
+		DurationInMinutes: &duration,
+		CaloriesBurned:    &calories,
+	})
+}
+
+func activityIds(t *testing.T, s ActivityService, filter repository.ActivityFilter) []string {
+	t.Helper()
+	activities, err := s.GetAll(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("GetAll(%+v): %v", filter, err)
+	}
+	ids := make([]string, len(activities))
+	for i, activity := range activities {
+		ids[i] = activity.Id
+	}
+	return ids
+}
+
+func TestGetAllFiltersAndOrdersActivities(t *testing.T) {
+	db := repository.NewMemory()
+	day := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	walk := addActivity(db, "jane", "Walking", day, 120)
+	run := addActivity(db, "jane", "Running", day.Add(48*time.Hour), 300)
+	yoga := addActivity(db, "jane", "Yoga", day.Add(24*time.Hour), 80)
+	addActivity(db, "john", "Running", day, 500)
+
+	s := newTestActivityService(t, db)
+	running := "Running"
+	from, to := day.Add(12*time.Hour), day.Add(36*time.Hour)
+	minCalories, maxCalories := 100, 200
+
+	for _, tc := range []struct {
+		name   string
+		filter repository.ActivityFilter
+		want   []string
+	}{
+		{"most recent first", repository.ActivityFilter{UserId: "jane", Limit: 5}, []string{run, yoga, walk}},
+		{"limit and offset", repository.ActivityFilter{UserId: "jane", Limit: 1, Offset: 1}, []string{yoga}},
+		{"offset past the end", repository.ActivityFilter{UserId: "jane", Limit: 5, Offset: 3}, []string{}},
+		{"activity type", repository.ActivityFilter{UserId: "jane", ActivityType: &running, Limit: 5}, []string{run}},
+		{"done at range", repository.ActivityFilter{UserId: "jane", DoneAtFrom: &from, DoneAtTo: &to, Limit: 5}, []string{yoga}},
+		{"calories range", repository.ActivityFilter{UserId: "jane", CaloriesBurnedMin: &minCalories, CaloriesBurnedMax: &maxCalories, Limit: 5}, []string{walk}},
+		{"unknown user", repository.ActivityFilter{UserId: "nobody", Limit: 5}, []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := activityIds(t, s, tc.filter)
+			if len(got) != len(tc.want) {
+				t.Fatalf("ids = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("ids = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAllPresentsTimesInUserTimezone(t *testing.T) {
+	db := repository.NewMemory()
+	email := "jane@example.com"
+	userId, err := db.Users().Register(context.Background(), &entity.User{Email: &email})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := db.Users().UpdateTimezone(context.Background(), userId, "Asia/Jakarta"); err != nil {
+		t.Fatalf("UpdateTimezone: %v", err)
+	}
+	doneAt := time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)
+	addActivity(db, userId, "Walking", doneAt, 120)
+
+	s := newTestActivityService(t, db)
+	loc, err := s.Location(context.Background(), userId)
+	if err != nil {
+		t.Fatalf("Location: %v", err)
+	}
+	if loc.String() != "Asia/Jakarta" {
+		t.Fatalf("Location = %s, want Asia/Jakarta", loc)
+	}
+
+	activities, err := s.GetAll(context.Background(), repository.ActivityFilter{UserId: userId, Timezone: loc.String(), Limit: 5})
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(activities) != 1 {
+		t.Fatalf("activities = %d, want 1", len(activities))
+	}
+	if got := activities[0].DoneAt.Format(time.RFC3339); got != "2026-10-02T03:00:00+07:00" {
+		t.Fatalf("DoneAt = %s, want 2026-10-02T03:00:00+07:00", got)
+	}
+}
+
+func TestLocationFallsBackToUTC(t *testing.T) {
+	s := newTestActivityService(t, repository.NewMemory())
+	loc, err := s.Location(context.Background(), "nobody")
+	if err != nil {
+		t.Fatalf("Location: %v", err)
+	}
+	if loc != time.UTC {
+		t.Fatalf("Location = %s, want UTC", loc)
+	}
+}
+
+type countingUsers struct {
+	repository.UserRepository
+	profiles int
+}
+
+func (u *countingUsers) GetProfile(ctx context.Context, id string) (*entity.User, error) {
+	u.profiles++
+	return u.UserRepository.GetProfile(ctx, id)
+}
+
+func TestLocationReadsTheCachedProfile(t *testing.T) {
+	db := repository.NewMemory()
+	email := "jane@example.com"
+	userId, err := db.Users().Register(context.Background(), &entity.User{Email: &email})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	users := &countingUsers{UserRepository: db.Users()}
+	store := newTestStore(t)
+	s := NewActivityService(db.Activities(), users, store, newTestLogger())
+	u := NewUserService(users, db, nil, store, newTestLogger())
+
+	for range 3 {
+		if _, err := s.Location(context.Background(), userId); err != nil {
+			t.Fatalf("Location: %v", err)
+		}
+		store.Wait()
+	}
+	if users.profiles != 1 {
+		t.Fatalf("profile read %d times, want once", users.profiles)
+	}
+
+	if _, err := u.UpdateTimezone(context.Background(), userId, &dto.RequestUpdateTimezone{Timezone: "Asia/Jakarta"}); err != nil {
+		t.Fatalf("UpdateTimezone: %v", err)
+	}
+	loc, err := s.Location(context.Background(), userId)
+	if err != nil {
+		t.Fatalf("Location: %v", err)
+	}
+	if loc.String() != "Asia/Jakarta" {
+		t.Fatalf("Location = %s after the update, want Asia/Jakarta", loc)
+	}
+}
+
+func TestCreateBatchInvalidatesCachedLists(t *testing.T) {
+	db := repository.NewMemory()
+	s := newTestActivityService(t, db)
+	day := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	walk := addActivity(db, "jane", "Walking", day, 120)
+
+	filter := repository.ActivityFilter{UserId: "jane", Limit: 5}
+	if got := activityIds(t, s, filter); len(got) != 1 || got[0] != walk {
+		t.Fatalf("ids = %v, want [%s]", got, walk)
+	}
+
+	running, duration, calories, doneAt := "Running", int64(30), int64(300), day.Add(24*time.Hour)
+	ids, err := s.CreateBatch(context.Background(), "jane", []entity.Activity{{
+		ActivityType: &running, DoneAt: &doneAt, DurationInMinutes: &duration, CaloriesBurned: &calories,
+	}})
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+
+	// The cached list above must not be served any more
+	if got := activityIds(t, s, filter); len(got) != 2 || got[0] != ids[0] {
+		t.Fatalf("ids = %v, want [%s %s]", got, ids[0], walk)
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TimDebug/FitByte/config"
+	"github.com/TimDebug/FitByte/domain"
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/helper"
+	"github.com/TimDebug/FitByte/logger"
+	"github.com/TimDebug/FitByte/repository"
+	"github.com/samber/do/v2"
+)
+
+const fileGCLookupBatchSize = 1000
+
+type FileSweepOptions struct {
+	MinAge time.Duration
+	DryRun bool
+	Prefix string
+}
+
+type FileGCService struct {
+	storage domain.StorageClient
+	refRepo repository.FileReferenceRepository
+	logger  logger.LogHandler
+
+	mu      sync.Mutex
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func NewFileGCService(
+	storage domain.StorageClient,
+	refRepo repository.FileReferenceRepository,
+	logger logger.LogHandler,
+) *FileGCService {
+	return &FileGCService{
+		storage: storage,
+		refRepo: refRepo,
+		logger:  logger,
+	}
+}
+
+func NewFileGCServiceInject(i do.Injector) (*FileGCService, error) {
+	_storage := do.MustInvoke[domain.StorageClient](i)
+	_refRepo := do.MustInvoke[repository.FileReferenceRepository](i)
+	_logger := do.MustInvoke[logger.LogHandler](i)
+	return NewFileGCService(_storage, _refRepo, _logger), nil
+}
+
+// Sweep deletes every stored file older than opts.MinAge that is no longer
+// referenced. With opts.DryRun the orphans are only reported.
+func (s *FileGCService) Sweep(ctx context.Context, opts FileSweepOptions) (*dto.FileSweepReport, error) {
+	files, err := s.storage.ListFiles(ctx, opts.Prefix)
+	if err != nil {
+		s.logger.For(ctx).Error(err.Error(), helper.FileGCServiceSweep)
+		return nil, err
+	}
+
+	report := &dto.FileSweepReport{
+		DryRun:       opts.DryRun,
+		Scanned:      len(files),
+		OrphanedKeys: make([]string, 0),
+	}
+
+	cutoff := time.Now().Add(-opts.MinAge)
+	candidates := make([]domain.StoredFile, 0, len(files))
+	for _, file := range files {
+		if file.LastModified.After(cutoff) {
+			continue
+		}
+		candidates = append(candidates, file)
+	}
+
+	for start := 0; start < len(candidates); start += fileGCLookupBatchSize {
+		end := min(start+fileGCLookupBatchSize, len(candidates))
+		batch := candidates[start:end]
+
+		keys := make([]string, len(batch))
+		for idx, file := range batch {
+			keys[idx] = file.Key
+		}
+		referenced, err := s.refRepo.FilterReferenced(ctx, keys)
+		if err != nil {
+			s.logger.For(ctx).Error(err.Error(), helper.FileGCServiceSweep)
+			return report, err
+		}
+
+		for _, file := range batch {
+			if _, ok := referenced[file.Key]; ok {
+				continue
+			}
+			report.Orphaned++
+			report.OrphanedKeys = append(report.OrphanedKeys, file.Key)
+
+			if opts.DryRun {
+				report.ReclaimedBytes += file.Size
+				continue
+			}
+			if err := s.storage.DeleteFile(ctx, file.Key); err != nil {
+				s.logger.For(ctx).Warn(err.Error(), helper.FileGCServiceSweep, file.Key)
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", file.Key, err))
+				continue
+			}
+			report.Deleted++
+			report.ReclaimedBytes += file.Size
+		}
+	}
+
+	s.logger.For(ctx).Info("file sweep finished", helper.FileGCServiceSweep, report)
+	return report, nil
+}
+
+// Schedule runs Sweep every cfg.Interval until Stop is called.
+func (s *FileGCService) Schedule(cfg config.FileGCConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
+
+	opts := FileSweepOptions{MinAge: cfg.MinAge, DryRun: cfg.DryRun, Prefix: cfg.Prefix}
+	go func(stop <-chan struct{}, stopped chan<- struct{}) {
+		defer close(stopped)
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := s.Sweep(context.Background(), opts); err != nil {
+					s.logger.Error(err.Error(), helper.FileGCServiceSchedule)
+				}
+			}
+		}
+	}(s.stop, s.stopped)
+}
+
+// Stop halts the scheduled sweeper and waits for a running sweep to return.
+func (s *FileGCService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.stopped
+	s.stop = nil
+	s.stopped = nil
+}
+
+// Shutdown stops the scheduled sweeper when the injector shuts down.
+func (s *FileGCService) Shutdown() {
+	s.Stop()
+}
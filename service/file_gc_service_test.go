@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/TimDebug/FitByte/domain"
+)
+
+type fakeFileStorage struct {
+	domain.StorageClient
+	files   []domain.StoredFile
+	deleted []string
+	failing string
+}
+
+func (s *fakeFileStorage) ListFiles(ctx context.Context, prefix string) ([]domain.StoredFile, error) {
+	return slices.Clone(s.files), nil
+}
+
+func (s *fakeFileStorage) DeleteFile(ctx context.Context, key string) error {
+	if key == s.failing {
+		return errors.New("storage unavailable")
+	}
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+type fakeFileReferences map[string]struct{}
+
+func (r fakeFileReferences) FilterReferenced(ctx context.Context, keys []string) (map[string]struct{}, error) {
+	referenced := make(map[string]struct{})
+	for _, key := range keys {
+		if _, ok := r[key]; ok {
+			referenced[key] = struct{}{}
+		}
+	}
+	return referenced, nil
+}
+
+func newTestFileGC() (*FileGCService, *fakeFileStorage) {
+	old := time.Now().Add(-48 * time.Hour)
+	storage := &fakeFileStorage{files: []domain.StoredFile{
+		{Key: "users/kept.jpg", Size: 10, LastModified: old},
+		{Key: "users/orphan.jpg", Size: 20, LastModified: old},
+		{Key: "users/orphan_2.jpg", Size: 40, LastModified: old},
+		{Key: "users/fresh.jpg", Size: 80, LastModified: time.Now()},
+	}}
+	refs := fakeFileReferences{"users/kept.jpg": {}}
+	return NewFileGCService(storage, refs, newTestLogger()), storage
+}
+
+func TestSweepDeletesOrphansPastTheGracePeriod(t *testing.T) {
+	gc, storage := newTestFileGC()
+
+	report, err := gc.Sweep(context.Background(), FileSweepOptions{MinAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"users/orphan.jpg", "users/orphan_2.jpg"}
+	if !slices.Equal(storage.deleted, want) {
+		t.Fatalf("deleted %v, want %v", storage.deleted, want)
+	}
+	if report.Scanned != 4 || report.Orphaned != 2 || report.Deleted != 2 || report.ReclaimedBytes != 60 {
+		t.Fatalf("report = %+v", report)
+	}
+}
+
+func TestSweepDryRunDeletesNothing(t *testing.T) {
+	gc, storage := newTestFileGC()
+
+	report, err := gc.Sweep(context.Background(), FileSweepOptions{MinAge: 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.deleted) != 0 {
+		t.Fatalf("dry run deleted %v", storage.deleted)
+	}
+	if report.Orphaned != 2 || report.Deleted != 0 || report.ReclaimedBytes != 60 {
+		t.Fatalf("report = %+v", report)
+	}
+}
+
+func TestSweepReportsFailedDeletes(t *testing.T) {
+	gc, storage := newTestFileGC()
+	storage.failing = "users/orphan.jpg"
+
+	report, err := gc.Sweep(context.Background(), FileSweepOptions{MinAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Orphaned != 2 || report.Deleted != 1 || report.ReclaimedBytes != 40 {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(report.Errors) != 1 {
+		t.Fatalf("errors = %v, want the failed delete", report.Errors)
+	}
+}
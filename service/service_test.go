@@ -0,0 +1,35 @@
+package service
+
+import (
+	"io"
+	"testing"
+
+	"github.com/TimDebug/FitByte/auth"
+	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/logger"
+	"github.com/TimDebug/FitByte/repository"
+)
+
+func newTestStore(t *testing.T) *cache.MemoryStore {
+	t.Helper()
+	store, err := cache.NewMemoryStore(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Shutdown() })
+	return store
+}
+
+func newTestLogger() logger.LogHandler {
+	return *logger.NewWriterLogHandler(io.Discard)
+}
+
+func newTestUserService(t *testing.T, db *repository.Memory) UserService {
+	t.Helper()
+	return NewUserService(db.Users(), db, auth.NewJWTService("test-secret"), newTestStore(t), newTestLogger())
+}
+
+func newTestActivityService(t *testing.T, db *repository.Memory) ActivityService {
+	t.Helper()
+	return NewActivityService(db.Activities(), db.Users(), newTestStore(t), newTestLogger())
+}
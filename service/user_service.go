@@ -1,62 +1,81 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/TimDebug/FitByte/auth"
 	"github.com/TimDebug/FitByte/cache"
+	"github.com/TimDebug/FitByte/database"
 	"github.com/TimDebug/FitByte/repository"
 	"github.com/TimDebug/FitByte/validation"
-	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/TimDebug/FitByte/dto"
 	"github.com/TimDebug/FitByte/entity"
 	"github.com/TimDebug/FitByte/helper"
 	"github.com/TimDebug/FitByte/logger"
+	"github.com/TimDebug/FitByte/metrics"
+	"github.com/TimDebug/FitByte/tracing"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/do/v2"
 )
 
-const (
-	isCachingBatchOfProfilesEnabled = true // Caching is suitable for read heavy operations
-	cacheDefaultTtl                 = 5 * time.Minute
-	maxMemoizedInvalidatedUserIds   = 100
-)
-
 type UserService struct {
-	userRepo repository.UserRepository
-	logger   logger.LogHandler
+	userRepo  repository.UserRepository
+	tx        database.Transactor
+	jwt       auth.Service
+	cache     cache.Store
+	namespace *cache.UserNamespace
+	profiles  *cache.Typed[dto.ResponseGetProfile]
+	logger    logger.LogHandler
 }
 
 func NewUserService(
 	userRepo repository.UserRepository,
+	tx database.Transactor,
+	jwt auth.Service,
+	store cache.Store,
 	logger logger.LogHandler,
 ) UserService {
 	return UserService{
-		userRepo: userRepo,
-		logger:   logger,
+		userRepo:  userRepo,
+		tx:        tx,
+		jwt:       jwt,
+		cache:     store,
+		namespace: cache.NewUserNamespace(store),
+		profiles:  newProfileCache(store),
+		logger:    logger,
 	}
 }
 
 func NewUserServiceInject(i do.Injector) (UserService, error) {
 	_userRepo := do.MustInvoke[repository.UserRepository](i)
+	_tx := do.MustInvoke[*database.TxManager](i)
+	_jwt := do.MustInvoke[auth.Service](i)
+	_cache := do.MustInvoke[cache.Store](i)
 	_logger := do.MustInvoke[logger.LogHandler](i)
-	return NewUserService(_userRepo, _logger), nil
+	return NewUserService(_userRepo, _tx, _jwt, _cache, _logger), nil
 }
 
-func (s *UserService) Login(ctx *gin.Context, body *dto.UserRequestPayload) (*dto.ResponseAuth, error) {
-	err := validation.ValidateUserCreate(*body)
+func (s *UserService) Login(ctx context.Context, body *dto.UserRequestPayload) (_ *dto.ResponseAuth, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer func() {
+		countAttempt(metrics.Logins, err)
+		tracing.End(span, err)
+	}()
+
+	err = validation.ValidateUserCreate(*body)
 	if err != nil {
 		return &dto.ResponseAuth{}, helper.NewErrorResponse(http.StatusBadRequest, err.Error())
 	}
 
 	users, err := s.userRepo.Login(ctx, body)
 	if err != nil {
-		s.logger.Error(err.Error(), helper.UserServiceLogin)
+		s.logger.For(ctx).Error(err.Error(), helper.UserServiceLogin)
 		return nil, err
 	}
 	if len(users) == 0 {
@@ -64,12 +83,17 @@ func (s *UserService) Login(ctx *gin.Context, body *dto.UserRequestPayload) (*dt
 	}
 
 	passwordHash := users[0].PasswordHash
+	start := time.Now()
 	err = bcrypt.CompareHashAndPassword([]byte(*passwordHash), []byte(body.Password))
+	metrics.BcryptDuration.WithLabelValues("compare").Observe(time.Since(start).Seconds())
 	if err != nil {
 		return nil, helper.NewErrorResponse(http.StatusBadRequest, err.Error())
 	}
 
-	cachedToken, found := cache.Get(fmt.Sprintf(cache.CacheAuthEmailToToken, body.Email))
+	cachedToken, found, err := s.cache.Get(ctx, fmt.Sprintf(cache.CacheAuthEmailToToken, body.Email))
+	if err != nil {
+		s.logger.For(ctx).Warn(err.Error(), helper.UserServiceLogin)
+	}
 	if found {
 		return &dto.ResponseAuth{
 			Email: body.Email,
@@ -77,103 +101,162 @@ func (s *UserService) Login(ctx *gin.Context, body *dto.UserRequestPayload) (*dt
 		}, nil
 	}
 
-	jwtService := auth.NewJWTService()
-	token, err := jwtService.GenerateToken(*users[0].Id)
+	token, err := s.jwt.GenerateToken(*users[0].Id)
 	if err != nil {
-		s.logger.Error(err.Error(), helper.UserServiceRegister, err)
+		s.logger.For(ctx).Error(err.Error(), helper.UserServiceRegister, err)
 		return nil, helper.NewErrorResponse(http.StatusInternalServerError, err.Error())
 	}
 	return &dto.ResponseAuth{Email: body.Email, Token: token}, nil
 }
 
-func (s *UserService) Register(ctx *gin.Context, body *dto.UserRequestPayload) (*dto.ResponseAuth, error) {
-	err := validation.ValidateUserCreate(*body)
+func (s *UserService) Register(ctx context.Context, body *dto.UserRequestPayload) (_ *dto.ResponseAuth, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer func() {
+		countAttempt(metrics.Registrations, err)
+		tracing.End(span, err)
+	}()
+
+	err = validation.ValidateUserCreate(*body)
 	if err != nil {
 		return &dto.ResponseAuth{}, helper.NewErrorResponse(http.StatusBadRequest, err.Error())
 	}
 
-	_, found := cache.Get(fmt.Sprintf(cache.CacheAuthEmailToToken, body.Email))
+	_, found, err := s.cache.Get(ctx, fmt.Sprintf(cache.CacheAuthEmailToToken, body.Email))
+	if err != nil {
+		s.logger.For(ctx).Warn(err.Error(), helper.UserServiceRegister)
+	}
 	if found {
 		return &dto.ResponseAuth{}, helper.ErrConflict
 	}
 
+	start := time.Now()
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.MinCost)
+	metrics.BcryptDuration.WithLabelValues("hash").Observe(time.Since(start).Seconds())
 	if err != nil {
-		s.logger.Error(err.Error(), helper.GenerateFromPassword, passwordHash)
+		s.logger.For(ctx).Error(err.Error(), helper.GenerateFromPassword, passwordHash)
 		return &dto.ResponseAuth{}, err
 	}
 
 	user := entity.User{}
 	user.Email = &body.Email
-	user.CreatedAt = time.Now().Unix()
+	user.CreatedAt = time.Now()
 	user.UpdatedAt = user.CreatedAt
 	password := string(passwordHash)
 	user.PasswordHash = &password
-	userId, err := s.userRepo.Register(ctx, &user)
-	if err != nil {
-		s.logger.Error(err.Error(), helper.UserServiceRegister)
-		if strings.Contains(err.Error(), "23505") {
-			return nil, helper.ErrConflict
+	// The existence check and the insert form one unit of work, later setup
+	// steps of the new account belong in the same transaction
+	var userId string
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.VerifyNewUser(ctx, body.Email); err != nil {
+			return err
 		}
+		id, err := s.userRepo.Register(ctx, &user)
+		userId = id
+		return err
+	})
+	if errors.Is(err, helper.ErrConflict) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.For(ctx).Error(err.Error(), helper.UserServiceRegister)
 		return nil, helper.NewErrorResponse(http.StatusInternalServerError, err.Error())
 	}
 
-	jwtService := auth.NewJWTService()
-	token, err := jwtService.GenerateToken(userId)
+	token, err := s.jwt.GenerateToken(userId)
 	if err != nil {
-		s.logger.Error(err.Error(), helper.UserServiceRegister, err)
+		s.logger.For(ctx).Error(err.Error(), helper.UserServiceRegister, err)
 		return nil, helper.NewErrorResponse(http.StatusInternalServerError, err.Error())
 	}
 
-	cache.Set(fmt.Sprintf(cache.CacheAuthEmailToToken, body.Email), token)
-	appendToInvalidatedUserIds(userId)
+	err = s.cache.Set(ctx, fmt.Sprintf(cache.CacheAuthEmailToToken, body.Email), token, cache.Ttl())
+	if err != nil {
+		s.logger.For(ctx).Warn(err.Error(), helper.UserServiceRegister)
+	}
+	if err := s.namespace.Bump(ctx, userId); err != nil {
+		s.logger.For(ctx).Warn(err.Error(), helper.UserServiceRegister)
+	}
 	return &dto.ResponseAuth{Email: body.Email, Token: token}, nil
 }
 
-// Get manager profile by their id
-func (s *UserService) GetProfile(ctx *gin.Context, id string) (*dto.ResponseGetProfile, error) {
+// Get user profile by their id, read through the cache
+func (s *UserService) GetProfile(ctx context.Context, id string) (_ *dto.ResponseGetProfile, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetProfile")
+	defer func() { tracing.End(span, err) }()
+
+	version, err := s.namespace.Version(ctx, id)
+	if err != nil {
+		s.logger.For(ctx).Warn(err.Error(), helper.UserServiceGetProfile)
+		return s.loadProfile(ctx, id)
+	}
+
+	key := fmt.Sprintf(cache.CacheUserIdToProfile, id, version)
+	result, err := s.profiles.GetOrLoad(ctx, key, cache.Ttl(), func(ctx context.Context) (dto.ResponseGetProfile, error) {
+		profile, err := s.loadProfile(ctx, id)
+		if err != nil {
+			return dto.ResponseGetProfile{}, err
+		}
+		return *profile, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *UserService) loadProfile(ctx context.Context, id string) (*dto.ResponseGetProfile, error) {
 	profile, err := s.userRepo.GetProfile(ctx, id)
 	if err != nil {
-		s.logger.Error(err.Error(), helper.UserServiceGetProfile, err)
+		s.logger.For(ctx).Error(err.Error(), helper.UserServiceGetProfile, err)
 		return nil, err
 	}
 
-	result := dto.ResponseGetProfile{
+	result := profileResponse(profile)
+	return &result, nil
+}
+
+// newProfileCache is shared by the services reading profiles, entries are
+// keyed by cache.CacheUserIdToProfile.
+func newProfileCache(store cache.Store) *cache.Typed[dto.ResponseGetProfile] {
+	return cache.NewTyped[dto.ResponseGetProfile]("profile", store, cache.JSONCodec)
+}
+
+func profileResponse(profile *entity.User) dto.ResponseGetProfile {
+	return dto.ResponseGetProfile{
 		Email:    *profile.Email,
 		Name:     profile.Name,
 		ImageUri: profile.ImageUri,
+		Timezone: profile.Timezone,
 	}
-	return &result, nil
 }
 
-func getValue(cache map[string]string, key string, asInt bool) interface{} {
-	val, exists := cache[key]
-	if !exists {
-		return nil
+// UpdateTimezone changes the timezone of the user's date filters and
+// responses and returns the updated profile.
+func (s *UserService) UpdateTimezone(ctx context.Context, id string, body *dto.RequestUpdateTimezone) (_ *dto.ResponseGetProfile, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateTimezone")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validation.ValidateUpdateTimezone(*body); err != nil {
+		return nil, helper.NewErrorResponse(http.StatusBadRequest, err.Error())
 	}
-	if asInt {
-		intVal, err := strconv.Atoi(val)
-		if err != nil {
-			return nil
+
+	if err := s.userRepo.UpdateTimezone(ctx, id, body.Timezone); err != nil {
+		if errors.Is(err, helper.ErrNotFound) {
+			return nil, err
 		}
-		return &intVal
+		s.logger.For(ctx).Error(err.Error(), helper.UserServiceUpdate, err)
+		return nil, helper.NewErrorResponse(http.StatusInternalServerError, err.Error())
 	}
-	return &val
-}
-
-func appendToInvalidatedUserIds(id string) {
-	invalidatedUserIds := make([]string, 0)
-	v, found := cache.Get(cache.CacheInvalidatedUserIds)
-	if found {
-		invalidatedUserIds = strings.Split(v, ",")
+	// Cached profiles and activity lists carry times in the old timezone
+	if err := s.namespace.Bump(ctx, id); err != nil {
+		s.logger.For(ctx).Warn(err.Error(), helper.UserServiceUpdate)
 	}
+	return s.loadProfile(ctx, id)
+}
 
-	if len(invalidatedUserIds) >= maxMemoizedInvalidatedUserIds {
-		// Rooms for improvement: use circular buffer data structure
-		// To replace oldest data with new data
+func countAttempt(counter *prometheus.CounterVec, err error) {
+	if err != nil {
+		counter.WithLabelValues(metrics.ResultFailure).Inc()
 		return
 	}
-
-	invalidatedUserIds = append(invalidatedUserIds, id)
-	cache.Set(cache.CacheInvalidatedUserIds, strings.Join(invalidatedUserIds, ","))
+	counter.WithLabelValues(metrics.ResultSuccess).Inc()
 }
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/TimDebug/FitByte/helper"
+	"github.com/TimDebug/FitByte/repository"
+)
+
+func TestRegisterThenLogin(t *testing.T) {
+	ctx := context.Background()
+	s := newTestUserService(t, repository.NewMemory())
+
+	registered, err := s.Register(ctx, &dto.UserRequestPayload{Email: "jane@example.com", Password: "correct-horse"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if registered.Email != "jane@example.com" || registered.Token == "" {
+		t.Fatalf("Register = %+v", registered)
+	}
+
+	loggedIn, err := s.Login(ctx, &dto.UserRequestPayload{Email: "jane@example.com", Password: "correct-horse"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if loggedIn.Token == "" {
+		t.Fatalf("Login = %+v", loggedIn)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		body   dto.UserRequestPayload
+		status int
+	}{
+		{"wrong password", dto.UserRequestPayload{Email: "jane@example.com", Password: "wrong-horse"}, http.StatusBadRequest},
+		{"unknown email", dto.UserRequestPayload{Email: "john@example.com", Password: "correct-horse"}, http.StatusNotFound},
+		{"invalid payload", dto.UserRequestPayload{Email: "jane", Password: "short"}, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.Login(ctx, &tc.body)
+			if got := helper.GetErrorStatusCode(err); got != tc.status {
+				t.Fatalf("Login error = %v (%d), want %d", err, got, tc.status)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsTakenEmail(t *testing.T) {
+	ctx := context.Background()
+	db := repository.NewMemory()
+	body := &dto.UserRequestPayload{Email: "jane@example.com", Password: "correct-horse"}
+
+	first := newTestUserService(t, db)
+	if _, err := first.Register(ctx, body); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// A fresh cache, so the conflict has to come from the repository
+	second := newTestUserService(t, db)
+	_, err := second.Register(ctx, body)
+	if !errors.Is(err, helper.ErrConflict) {
+		t.Fatalf("second Register = %v, want %v", err, helper.ErrConflict)
+	}
+}
+
+func TestGetProfile(t *testing.T) {
+	ctx := context.Background()
+	db := repository.NewMemory()
+	s := newTestUserService(t, db)
+
+	if _, err := s.Register(ctx, &dto.UserRequestPayload{Email: "jane@example.com", Password: "correct-horse"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	users, _ := db.Users().Login(ctx, &dto.UserRequestPayload{Email: "jane@example.com"})
+
+	profile, err := s.GetProfile(ctx, *users[0].Id)
+	if err != nil {
+		t.Fatalf("GetProfile: %v", err)
+	}
+	if profile.Email != "jane@example.com" {
+		t.Fatalf("GetProfile = %+v", profile)
+	}
+
+	if _, err := s.GetProfile(ctx, "unknown"); !errors.Is(err, helper.ErrNotFound) {
+		t.Fatalf("GetProfile of an unknown id = %v, want %v", err, helper.ErrNotFound)
+	}
+}
@@ -0,0 +1,133 @@
+package pgtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const DefaultPassword = "password123"
+
+// User is a user inserted by UserBuilder, Password is the plaintext.
+type User struct {
+	Id       string
+	Email    string
+	Password string
+}
+
+type UserBuilder struct {
+	db       *DB
+	email    string
+	password string
+	name     *string
+	timezone string
+}
+
+// NewUser builds a user with a unique email and DefaultPassword.
+func (db *DB) NewUser() *UserBuilder {
+	db.sequence++
+	return &UserBuilder{
+		db:       db,
+		email:    fmt.Sprintf("user%d@example.com", db.sequence),
+		password: DefaultPassword,
+		timezone: "UTC",
+	}
+}
+
+func (b *UserBuilder) Email(email string) *UserBuilder {
+	b.email = email
+	return b
+}
+
+func (b *UserBuilder) Password(password string) *UserBuilder {
+	b.password = password
+	return b
+}
+
+func (b *UserBuilder) Name(name string) *UserBuilder {
+	b.name = &name
+	return b
+}
+
+func (b *UserBuilder) Timezone(timezone string) *UserBuilder {
+	b.timezone = timezone
+	return b
+}
+
+func (b *UserBuilder) Create(t testing.TB) User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(b.password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := User{Email: b.email, Password: b.password}
+	err = b.db.Pool.QueryRow(context.Background(), `
+		INSERT INTO Users (email, password_hash, name, timezone)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, b.email, string(hash), b.name, b.timezone).Scan(&user.Id)
+	if err != nil {
+		t.Fatalf("pgtest: create user: %v", err)
+	}
+	return user
+}
+
+type ActivityBuilder struct {
+	db                *DB
+	userId            string
+	activityType      string
+	doneAt            time.Time
+	durationInMinutes int
+	caloriesBurned    int
+}
+
+// NewActivity builds a 30 minute walk of userId done an hour ago.
+func (db *DB) NewActivity(userId string) *ActivityBuilder {
+	return &ActivityBuilder{
+		db:                db,
+		userId:            userId,
+		activityType:      "Walking",
+		doneAt:            time.Now().Add(-time.Hour).Truncate(time.Second),
+		durationInMinutes: 30,
+		caloriesBurned:    120,
+	}
+}
+
+func (b *ActivityBuilder) Type(activityType string) *ActivityBuilder {
+	b.activityType = activityType
+	return b
+}
+
+func (b *ActivityBuilder) DoneAt(doneAt time.Time) *ActivityBuilder {
+	b.doneAt = doneAt
+	return b
+}
+
+func (b *ActivityBuilder) Duration(minutes int) *ActivityBuilder {
+	b.durationInMinutes = minutes
+	return b
+}
+
+func (b *ActivityBuilder) Calories(calories int) *ActivityBuilder {
+	b.caloriesBurned = calories
+	return b
+}
+
+// Create inserts the activity and returns its id.
+func (b *ActivityBuilder) Create(t testing.TB) string {
+	t.Helper()
+	var id string
+	err := b.db.Pool.QueryRow(context.Background(), `
+		INSERT INTO Activities (user_id, activity_type, done_at, duration_in_minutes, calories_burned)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, b.userId, b.activityType, b.doneAt.UTC(), b.durationInMinutes, b.caloriesBurned).Scan(&id)
+	if err != nil {
+		t.Fatalf("pgtest: create activity: %v", err)
+	}
+	return id
+}
@@ -0,0 +1,48 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/TimDebug/FitByte/dto"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidateActivity returns every invalid field of input by its JSON name,
+// nil when input is valid.
+func ValidateActivity(input dto.RequestActivity) map[string]string {
+	err := validate.Struct(input)
+	if err == nil {
+		return nil
+	}
+
+	fields := make(map[string]string)
+	t := reflect.TypeOf(input)
+	for _, fieldError := range err.(validator.ValidationErrors) {
+		fields[jsonName(t, fieldError.StructField())] = describe(fieldError)
+	}
+	return fields
+}
+
+func jsonName(t reflect.Type, field string) string {
+	if f, ok := t.FieldByName(field); ok {
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+			return name
+		}
+	}
+	return field
+}
+
+func describe(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s", fieldError.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fieldError.Param())
+	default:
+		return fmt.Sprintf("failed the %s rule", fieldError.Tag())
+	}
+}
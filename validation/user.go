@@ -45,3 +45,14 @@ func ValidateUpdateProfile(input dto.RequestUpdateProfile) error {
 	}
 	return nil
 }
+
+func ValidateUpdateTimezone(input dto.RequestUpdateTimezone) error {
+	err := validate.Struct(input)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		for _, fieldError := range validationErrors {
+			return fieldError
+		}
+	}
+	return nil
+}